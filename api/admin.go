@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	responseKeyProblems = "problems"
+	responseKeyFixed    = "fixed"
+)
+
+// Validate reports every broken invariant of the hierarchy as it is stored
+// in the persistent layer.
+func (c Controller) Validate(req core.Request) core.ResponseWriter {
+	nodes, err := c.store.GetNodes()
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyProblems, graph.Validate(nodes)).Writer
+}
+
+// Repair fixes the hierarchy in the persistent layer and rebuilds the in memory
+// cache from the repaired nodes. Returns the problems found before the repair
+// and the nodes that got a new parent or height.
+func (c Controller) Repair(req core.Request) core.ResponseWriter {
+	var problems []graph.Problem
+	var fixed []*graph.Node
+	err := c.g.Update(func(g *graph.Graph) error {
+		nodes, err := c.store.GetNodes()
+		if err != nil {
+			return err
+		}
+		problems = graph.Validate(nodes)
+		fixed = graph.Repair(nodes)
+		if len(fixed) == 0 {
+			return nil
+		}
+		byID := make(map[string]*graph.Node, len(fixed))
+		for _, node := range fixed {
+			byID[node.ID] = node
+		}
+		for i, node := range nodes {
+			if f, ok := byID[node.ID]; ok {
+				nodes[i] = f
+			}
+			// Children are linked anew from the repaired parents
+			nodes[i].Children = nil
+		}
+		repaired, err := graph.Initialize(nodes)
+		if err != nil {
+			return err
+		}
+		repaired.Edges = g.Edges
+		*g = *repaired
+		return c.logged(req, graph.EventRepair, g).UpdateNodes(fixed)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).
+		Data(responseKeyProblems, problems).
+		Data(responseKeyFixed, flatten(fixed)).Writer
+}
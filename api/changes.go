@@ -0,0 +1,220 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/storage"
+	"github.com/DeshErBojhaa/tradeshift/storage/mysql"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	pathParamChangeID   = "changeID"
+	queryParamSince     = "since"
+	queryParamLimit     = "limit"
+	queryParamUntil     = "until"
+	headerActor         = "X-Actor"
+	actorAnonymous      = "anonymous"
+	actorSystem         = "system"
+	actorCLI            = "cli"
+	responseKeyChanges  = "changes"
+	responseKeyChange   = "change"
+	responseKeyReplayed = "replayed"
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+)
+
+// GetChanges returns the change log in order. 'since' skips every change up
+// to and including that sequence number, 'limit' caps the number of changes.
+func (c Controller) GetChanges(req core.Request) core.ResponseWriter {
+	since, err := int64Param(req, queryParamSince, 0)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	limit, err := int64Param(req, queryParamLimit, defaultChangesLimit)
+	if err != nil || limit <= 0 || limit > maxChangesLimit {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("%s must be between 1 and %d", queryParamLimit, maxChangesLimit)).Writer
+	}
+	events, err := c.store.GetEvents(since, int(limit))
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyChanges, events).Writer
+}
+
+// GetChange returns a single change of the change log.
+func (c Controller) GetChange(req core.Request) core.ResponseWriter {
+	e, errResp := c.change(req)
+	if errResp != nil {
+		return errResp
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyChange, e).Writer
+}
+
+// Revert undoes the change given by the 'changeID' path param, as long as the
+// hierarchy still allows it, see graph.Graph.Reverted. The revert is recorded
+// as a change of it's own and returned.
+func (c Controller) Revert(req core.Request) core.ResponseWriter {
+	target, errResp := c.change(req)
+	if errResp != nil {
+		return errResp
+	}
+
+	var ev *graph.Event
+	err := c.g.Update(func(g *graph.Graph) error {
+		reverted, err := g.Reverted(target)
+		if err != nil {
+			return statusError{http.StatusConflict, fmt.Errorf("change %d can not be reverted: %v", target.Seq, err)}
+		}
+		before := c.g.Snapshot()
+		*g = *reverted
+
+		switch target.Kind {
+		case graph.EventEdgeAdd:
+			ev = graph.NewEdgeEvent(graph.EventEdgeRemove, actor(req), target.Edge)
+			ev.Reverts = target.Seq
+			return c.store.WithEvent(ev).DeleteEdge(target.Edge)
+		case graph.EventEdgeRemove:
+			ev = graph.NewEdgeEvent(graph.EventEdgeAdd, actor(req), target.Edge)
+			ev.Reverts = target.Seq
+			return c.store.WithEvent(ev).InsertEdge(target.Edge)
+		}
+
+		if target.Kind == graph.EventRename {
+			ev = graph.NewEvent(graph.EventRename, actor(req), before, g)
+			ev.Rename = &graph.Rename{From: target.Rename.To, To: target.Rename.From}
+			ev.Reverts = target.Seq
+			return c.store.WithEvent(ev).RenameNode(before.Nodes[ev.Rename.From], ev.Rename.To)
+		}
+
+		ev = graph.NewEvent(graph.EventRevert, actor(req), before, g)
+		ev.Reverts = target.Seq
+		nodes := make([]*graph.Node, 0, len(ev.After))
+		for _, t := range ev.After {
+			nodes = append(nodes, g.Nodes[t.ID])
+		}
+		removed := make([]*graph.Node, 0)
+		for _, t := range ev.Before {
+			if _, ok := g.Nodes[t.ID]; !ok {
+				removed = append(removed, before.Nodes[t.ID])
+			}
+		}
+		return c.store.WithEvent(ev).ReplaceNodes(nodes, removed)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyChange, ev).Writer
+}
+
+// Replay rebuilds the hierarchy from the change log, up to and including the
+// change given by the optional 'until' query param, and returns how it differs
+// from the live hierarchy. Replaying the whole log gives an empty diff.
+func (c Controller) Replay(req core.Request) core.ResponseWriter {
+	until, err := int64Param(req, queryParamUntil, -1)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	// Read the live graph first, changes made meanwhile only show up in the diff
+	live := c.g.Snapshot()
+	events, err := c.eventsUntil(until)
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	replayed, err := graph.Replay(events)
+	if err != nil {
+		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).
+		Data(responseKeyReplayed, len(events)).
+		Data(responseKeyDiff, graph.Compare(replayed, live)).Writer
+}
+
+// change loads the change given by the 'changeID' path param.
+func (c Controller) change(req core.Request) (*graph.Event, core.ResponseWriter) {
+	v, _ := req.PathParam(pathParamChangeID)
+	seq, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || seq <= 0 {
+		return nil, NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid change id: %s", v)).Writer
+	}
+	events, err := c.store.GetEvents(seq-1, 1)
+	if err != nil {
+		return nil, NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	if len(events) == 0 || events[0].Seq != seq {
+		return nil, NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("change %d not found", seq)).Writer
+	}
+	return events[0], nil
+}
+
+// eventsUntil loads the change log page by page, up to and including 'until'.
+// A negative 'until' loads the whole log.
+func (c Controller) eventsUntil(until int64) ([]*graph.Event, error) {
+	events := make([]*graph.Event, 0)
+	var since int64
+	for {
+		page, err := c.store.GetEvents(since, maxChangesLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			if until >= 0 && e.Seq > until {
+				return events, nil
+			}
+			events = append(events, e)
+		}
+		if len(page) < maxChangesLimit {
+			return events, nil
+		}
+		since = page[len(page)-1].Seq
+	}
+}
+
+// logged returns a store that appends the change from the latest published
+// version of the graph to 'g' to the change log, along with the next mutation.
+// It must be called from within graph.Shared.Update, after 'g' is modified.
+func (c Controller) logged(req core.Request, kind graph.EventKind, g *graph.Graph) storage.Persister {
+	return c.store.WithEvent(graph.NewEvent(kind, actor(req), c.g.Snapshot(), g))
+}
+
+// actor is who made the request, as told by the 'X-Actor' header.
+func actor(req core.Request) string {
+	if a := req.Header(headerActor); a != "" {
+		return a
+	}
+	return actorAnonymous
+}
+
+// initEventLog starts an empty change log with the hierarchy as it is, so
+// that replaying the log rebuilds it.
+func initEventLog(db *mysql.MySQL, g *graph.Graph) error {
+	events := []*graph.Event{graph.NewEvent(graph.EventBaseline, actorSystem, &graph.Graph{}, g)}
+	for _, edge := range g.Edges {
+		events = append(events, graph.NewEdgeEvent(graph.EventEdgeAdd, actorSystem, edge))
+	}
+	return db.InitEventLog(events)
+}
+
+func int64Param(req core.Request, key string, def int64) (int64, error) {
+	v, ok := req.QueryParam(key)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", key, v)
+	}
+	return n, nil
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/DeshErBojhaa/tradeshift/graph"
 	"github.com/DeshErBojhaa/tradeshift/storage"
@@ -13,6 +15,17 @@ import (
 const (
 	pathParamID       = "id"
 	pathParanParentID = "parid"
+	pathParamOtherID  = "other"
+	pathParamNewID    = "newid"
+	queryParamDepth   = "max_depth"
+	queryParamFormat  = "format"
+	formatFlat        = "flat"
+	formatNested      = "nested"
+	queryParamMode    = "mode"
+	modeLift          = "lift"
+	modeSubtree       = "subtree"
+	queryParamStrat   = "strategy"
+	queryParamAsOf    = "as_of"
 	responseKeyNode   = "node"
 	responseKeyErrors = "errors"
 	errorBadBody      = "invalid request body"
@@ -22,27 +35,183 @@ const (
 type Controller struct {
 	store    storage.Persister
 	validate *validator.Validate
-	g        *graph.Graph
+	g        *graph.Shared
 }
 
 // GetChildren returns all children of a given node. For fast response time
 // we first try to return from the in memory cache. i.e. 'graph'
+// The children can be sorted with 'sort' and 'order', filtered by 'name',
+// 'title', 'email' and 'label', and paged with 'limit' and 'cursor'. The
+// cursor of the next page is returned as 'next'. The shape of the children
+// is chosen with 'format', 'max_depth' and 'fields', see view.
 func (c Controller) GetChildren(req core.Request) core.ResponseWriter {
 	id, ok := req.PathParam(pathParamID)
 	if !ok {
 		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
 			Data(responseKeyErrors, errorBadBody).Writer
 	}
-	children, err := c.g.GetChildren(id)
+	q, err := childrenQuery(req)
 	if err != nil {
-		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	if _, ok := g.Nodes[id]; !ok {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid id %s", id)).Writer
+	}
+	page, err := g.QueryChildren(id, q)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	resp := NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, v.nodes(page.Nodes))
+	if page.Next != "" {
+		resp = resp.Data(responseKeyNext, page.Next)
+	}
+	return resp.Writer
+}
+
+// GetDescendants returns every node under a given node. The walk can be limited
+// with the 'max_depth' query param. With 'format=nested' the subtree is returned
+// as a nested tree, otherwise as a flat list in breadth first order where each
+// node lists it's children by id. 'fields' picks the fields of each node, see view.
+func (c Controller) GetDescendants(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	if !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	maxDepth := graph.NoDepthLimit
+	if v, ok := req.QueryParam(queryParamDepth); ok {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 0 {
+			return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+				Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", queryParamDepth, v)).Writer
+		}
+		maxDepth = depth
+	}
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+
+	format, _ := req.QueryParam(queryParamFormat)
+	switch format {
+	case "", formatFlat:
+		descendants, err := g.Descendants(id, maxDepth)
+		if err != nil {
+			return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+				Data(responseKeyErrors, err.Error()).Writer
+		}
+		return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, v.nodes(descendants)).Writer
+	case formatNested:
+		tree, err := g.Subtree(id, maxDepth)
+		if err != nil {
+			return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+				Data(responseKeyErrors, err.Error()).Writer
+		}
+		return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, v.project(tree)).Writer
+	default:
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", queryParamFormat, format)).Writer
+	}
+}
+
+// GetAncestors returns the chain of command of a given node, from it's parent
+// up to the root.
+func (c Controller) GetAncestors(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	if !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
 			Data(responseKeyErrors, err.Error()).Writer
 	}
-	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, children).Writer
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	ancestors, err := g.Ancestors(id)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, v.nodes(ancestors)).Writer
+}
+
+// GetLowestCommonAncestor returns the first shared manager of two nodes.
+func (c Controller) GetLowestCommonAncestor(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	other, okOther := req.PathParam(pathParamOtherID)
+	if !ok || !okOther {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	lca, err := g.LowestCommonAncestor(id, other)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, v.node(lca)).Writer
+}
+
+// GetPath returns the nodes on the way from one node to another, going up
+// to their lowest common ancestor and then down.
+func (c Controller) GetPath(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	other, okOther := req.PathParam(pathParamOtherID)
+	if !ok || !okOther {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	path, err := g.Path(id, other)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, v.nodes(path)).Writer
 }
 
-// UpdateParent changes parent of a given node. First chenge the underlying
-// persistence storage. If that succeeds, update the in memory cache.
+// UpdateParent changes parent of a given node. First change the in memory
+// cache, then the underlying persistence storage along with the change log.
+// By default the children of the node are lifted to it's old parent. With
+// 'mode=subtree' the whole subtree moves along with the node.
 func (c Controller) UpdateParent(req core.Request) core.ResponseWriter {
 	id, ok := req.PathParam(pathParamID)
 	if !ok {
@@ -58,48 +227,318 @@ func (c Controller) UpdateParent(req core.Request) core.ResponseWriter {
 		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
 			Data(responseKeyErrors, fmt.Sprintln("self cycle is not allowed")).Writer
 	}
-
-	curNode, newPar := c.g.Nodes[id], c.g.Nodes[parID]
-	if curNode == nil || newPar == nil {
+	mode, _ := req.QueryParam(queryParamMode)
+	if mode != "" && mode != modeLift && mode != modeSubtree {
 		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
-			Data(responseKeyErrors, fmt.Sprintf("invalid id: %s or parent id: %s", id, parID)).Writer
+			Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", queryParamMode, mode)).Writer
 	}
-
-	if err := c.store.UpdateParent(curNode, newPar); err != nil {
-		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
 			Data(responseKeyErrors, err.Error()).Writer
 	}
 
-	if err := c.g.UpdateParent(id, parID); err != nil {
-		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
-			Data(responseKeyErrors, err.Error()).Writer
+	var resp interface{}
+	err = c.g.Update(func(g *graph.Graph) error {
+		curNode, newPar := g.Nodes[id], g.Nodes[parID]
+		if curNode == nil || newPar == nil {
+			return statusError{http.StatusBadRequest, fmt.Errorf("invalid id: %s or parent id: %s", id, parID)}
+		}
+		if mode == modeSubtree {
+			if err := c.moveSubtree(req, g, id, parID); err != nil {
+				return err
+			}
+			resp = v.node(curNode)
+			return nil
+		}
+
+		before := c.g.Snapshot()
+		descendants, err := before.Descendants(id, graph.NoDepthLimit)
+		if err != nil {
+			return err
+		}
+		if err := g.UpdateParent(id, parID); err != nil {
+			return err
+		}
+		resp = v.node(curNode)
+		// The new parent may be one of the lifted nodes, it's height is the one after the move
+		return c.logged(req, graph.EventUpdateParent, g).UpdateParent(before.Nodes[id], g.Nodes[parID], descendants)
+	})
+	if err != nil {
+		return errorResponse(err)
 	}
-	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, curNode).Writer
+	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, resp).Writer
+}
+
+// moveSubtree moves node 'id' along with all of it's descendants under 'parID'.
+func (c Controller) moveSubtree(req core.Request, g *graph.Graph, id, parID string) error {
+	if err := g.CheckMoveSubtree(id, parID); err != nil {
+		return statusError{http.StatusBadRequest, err}
+	}
+	before := c.g.Snapshot()
+	descendants, err := before.Descendants(id, graph.NoDepthLimit)
+	if err != nil {
+		return err
+	}
+
+	if err := g.MoveSubtree(id, parID); err != nil {
+		return err
+	}
+	return c.logged(req, graph.EventMoveSubtree, g).MoveSubtree(before.Nodes[id], before.Nodes[parID], descendants)
 }
 
 // Create adds an node to storage, updates the in-memory cache and returns the node.
+// The node goes after it's last sibling, unless a sibling is given with the
+// 'before' or 'after' query param.
 func (c Controller) Create(req core.Request) core.ResponseWriter {
 	node := graph.NewEmptyNode()
 	if err := req.JSON(&node); err != nil {
 		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
 			Data(responseKeyErrors, errorBadBody).Writer
 	}
-
-	// child node. Get height from it's parent.
-	if node.ParID != "" {
-		parent := c.g.Nodes[node.ParID]
-		node.Height = parent.Height + 1
+	sibling, after, placed := placement(req)
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
 	}
 
-	if err := c.store.InsertNode(&node); err != nil {
-		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+	if err := c.validate.Struct(node.Attributes); err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
 			Data(responseKeyErrors, err.Error()).Writer
 	}
 
-	if err := c.g.EmplaceNode(&node); err != nil {
-		return NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+	err = c.g.Update(func(g *graph.Graph) error {
+		// child node. Get height from it's parent.
+		if parent, ok := g.Nodes[node.ParID]; ok {
+			node.Height = parent.Height + 1
+		}
+		if err := g.CheckEmplaceNode(&node); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+
+		if err := g.EmplaceNode(&node); err != nil {
+			return err
+		}
+		if !placed {
+			return c.logged(req, graph.EventCreate, g).InsertNode(&node)
+		}
+
+		if err := g.CheckPlaceNode(node.ID, sibling); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+		changed, err := g.PlaceNode(node.ID, sibling, after)
+		if err != nil {
+			return err
+		}
+		nodes := []*graph.Node{&node}
+		for _, n := range changed {
+			if n != &node {
+				nodes = append(nodes, n)
+			}
+		}
+		return c.logged(req, graph.EventCreate, g).ReplaceNodes(nodes, nil)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, v.node(&node)).Writer
+}
+
+// UpdateAttributes patches the attributes of a given node. Fields missing from
+// the body are left unchanged. Labels are merged, a label set to null is removed.
+func (c Controller) UpdateAttributes(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	if !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintln("id not found in request")).Writer
+	}
+	var patch graph.AttributesPatch
+	if err := req.JSON(&patch); err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+
+	var resp *graph.Tree
+	err := c.g.Update(func(g *graph.Graph) error {
+		curNode := g.Nodes[id]
+		if curNode == nil {
+			return statusError{http.StatusNotFound, fmt.Errorf("invalid id: %s", id)}
+		}
+		attrs := patch.Apply(curNode.Attributes)
+		if err := c.validate.Struct(attrs); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+
+		if err := g.UpdateAttributes(id, attrs); err != nil {
+			return err
+		}
+		resp = curNode.Flat()
+		return c.logged(req, graph.EventAttributes, g).UpdateAttributes(curNode, attrs)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, resp).Writer
+}
+
+// RenameNode changes the id of a given node. It's children and secondary
+// edges follow the new id.
+func (c Controller) RenameNode(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	newID, okNew := req.PathParam(pathParamNewID)
+	if !ok || !okNew {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintln("id or new id not found in request")).Writer
+	}
+
+	var resp *graph.Tree
+	err := c.g.Update(func(g *graph.Graph) error {
+		if _, ok := g.Nodes[id]; !ok {
+			return statusError{http.StatusNotFound, fmt.Errorf("invalid id: %s", id)}
+		}
+		if err := g.CheckRenameNode(id, newID); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+
+		before := c.g.Snapshot()
+		if err := g.RenameNode(id, newID); err != nil {
+			return err
+		}
+		resp = g.Nodes[newID].Flat()
+		ev := graph.NewEvent(graph.EventRename, actor(req), before, g)
+		ev.Rename = &graph.Rename{From: id, To: newID}
+		return c.store.WithEvent(ev).RenameNode(before.Nodes[id], newID)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, resp).Writer
+}
+
+// SwapNodes swaps the positions of two nodes. Each takes the parent and the
+// children of the other, so both teams stay as they are.
+func (c Controller) SwapNodes(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	other, okOther := req.PathParam(pathParamOtherID)
+	if !ok || !okOther {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+
+	var moved []*graph.Node
+	err := c.g.Update(func(g *graph.Graph) error {
+		if err := g.CheckSwapNodes(id, other); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+		var err error
+		if moved, err = g.SwapNodes(id, other); err != nil {
+			return err
+		}
+		return c.logged(req, graph.EventSwap, g).UpdateNodes(moved)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, flatten(moved)).Writer
+}
+
+// flatten drops the children of the given nodes, so that serializing a list
+// of nodes does not serialize every subtree along with it.
+func flatten(nodes []*graph.Node) []*graph.Tree {
+	flat := make([]*graph.Tree, len(nodes))
+	for i, node := range nodes {
+		flat[i] = node.Flat()
+	}
+	return flat
+}
+
+// Delete removes a node. The 'strategy' query param decides what happens to
+// it's children: 'refuse' (default) fails if there are any, 'reparent'
+// attaches them to the parent of the removed node and 'cascade' removes them too.
+func (c Controller) Delete(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	if !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintln("id not found in request")).Writer
+	}
+	strategy := graph.DeleteRefuse
+	if v, ok := req.QueryParam(queryParamStrat); ok {
+		strategy = graph.DeleteStrategy(v)
+	}
+
+	var removed []*graph.Node
+	err := c.g.Update(func(g *graph.Graph) error {
+		curNode := g.Nodes[id]
+		if curNode == nil {
+			return statusError{http.StatusNotFound, fmt.Errorf("invalid id: %s", id)}
+		}
+		if err := g.CheckRemoveNode(id, strategy); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+		before := c.g.Snapshot()
+		descendants, err := before.Descendants(id, graph.NoDepthLimit)
+		if err != nil {
+			return err
+		}
+
+		if removed, err = g.RemoveNode(id, strategy); err != nil {
+			return err
+		}
+		return c.logged(req, graph.EventDelete, g).DeleteNode(before.Nodes[id], strategy, descendants)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, flatten(removed)).Writer
+}
+
+// graphAsOf returns the graph to answer a read query from. Without the 'as_of'
+// query param that is the latest snapshot. Otherwise the hierarchy as it was at
+// that time is loaded from the persistent layer. 'as_of' is either RFC 3339 or
+// a date, which means the start of that day in UTC.
+func (c Controller) graphAsOf(req core.Request) (*graph.Graph, core.ResponseWriter) {
+	v, ok := req.QueryParam(queryParamAsOf)
+	if !ok {
+		return c.g.Snapshot(), nil
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if at, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+				Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", queryParamAsOf, v)).Writer
+		}
+	}
+	nodes, err := c.store.GetNodesAsOf(at)
+	if err != nil {
+		return nil, NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
 			Data(responseKeyErrors, err.Error()).Writer
 	}
+	g, err := graph.Initialize(nodes)
+	if err != nil {
+		return nil, NewResponse(http.StatusInternalServerError, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return g, nil
+}
 
-	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, node).Writer
+// statusError carries the http status of a failed graph update out of graph.Shared.Update.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e statusError) Error() string {
+	return e.err.Error()
+}
+
+// errorResponse writes the error along with it's status. Errors without a
+// status are considered internal errors.
+func errorResponse(err error) core.ResponseWriter {
+	status := http.StatusInternalServerError
+	if se, ok := err.(statusError); ok {
+		status = se.status
+	}
+	return NewResponse(status, core.MediaTypeJSON).Data(responseKeyErrors, err.Error()).Writer
 }
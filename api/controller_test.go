@@ -0,0 +1,299 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/storage"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+	. "github.com/onsi/gomega"
+	"gopkg.in/go-playground/validator.v8"
+)
+
+// fakeRequest is a core.Request with fixed params and body.
+type fakeRequest struct {
+	path  map[string]string
+	query map[string]string
+	body  string
+}
+
+func (r fakeRequest) PathParam(key string) (string, bool) {
+	v, ok := r.path[key]
+	return v, ok
+}
+
+func (r fakeRequest) QueryParam(key string) (string, bool) {
+	v, ok := r.query[key]
+	return v, ok
+}
+
+func (r fakeRequest) JSON(target interface{}) error {
+	return json.NewDecoder(r.Body()).Decode(target)
+}
+
+func (r fakeRequest) Body() io.Reader {
+	return strings.NewReader(r.body)
+}
+
+func (r fakeRequest) Header(key string) string {
+	return ""
+}
+
+// write is a single call of a write method of fakeStore, with the nodes as
+// they were handed over.
+type write struct {
+	method  string
+	nodes   []*graph.Tree
+	removed []string
+}
+
+// fakeLog is what fakeStore persisted, shared by the copies WithEvent makes.
+type fakeLog struct {
+	writes []write
+	events []*graph.Event
+}
+
+// fakeStore is a storage.Persister in memory. It only keeps the change log
+// and records the writes, the nodes live in the graph of the controller.
+type fakeStore struct {
+	*fakeLog
+	pending *graph.Event
+}
+
+func (s fakeStore) record(method string, nodes []*graph.Node, removed []*graph.Node) error {
+	w := write{method: method, nodes: make([]*graph.Tree, 0), removed: make([]string, 0)}
+	for _, node := range nodes {
+		w.nodes = append(w.nodes, node.Flat())
+	}
+	for _, node := range removed {
+		w.removed = append(w.removed, node.ID)
+	}
+	s.writes = append(s.writes, w)
+	if s.pending == nil {
+		return nil
+	}
+	// Stored events are copies, as they would be after a round trip to the database
+	b, err := json.Marshal(s.pending)
+	if err != nil {
+		return err
+	}
+	e := &graph.Event{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return err
+	}
+	e.Seq = int64(len(s.events) + 1)
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s fakeStore) GetNodes() ([]*graph.Node, error) { return nil, nil }
+
+func (s fakeStore) GetNodesAsOf(at time.Time) ([]*graph.Node, error) { return nil, nil }
+
+func (s fakeStore) InsertNode(node *graph.Node) error {
+	return s.record("InsertNode", []*graph.Node{node}, nil)
+}
+
+func (s fakeStore) InsertNodes(nodes []*graph.Node) error {
+	return s.record("InsertNodes", nodes, nil)
+}
+
+func (s fakeStore) UpdateParent(curNode, targetNode *graph.Node, descendants []*graph.Node) error {
+	return s.record("UpdateParent", []*graph.Node{curNode, targetNode}, nil)
+}
+
+func (s fakeStore) MoveSubtree(curNode, targetNode *graph.Node, descendants []*graph.Node) error {
+	return s.record("MoveSubtree", []*graph.Node{curNode, targetNode}, nil)
+}
+
+func (s fakeStore) DeleteNode(curNode *graph.Node, strategy graph.DeleteStrategy, descendants []*graph.Node) error {
+	return s.record("DeleteNode", nil, append([]*graph.Node{curNode}, descendants...))
+}
+
+func (s fakeStore) UpdateAttributes(curNode *graph.Node, attrs graph.Attributes) error {
+	return s.record("UpdateAttributes", []*graph.Node{curNode}, nil)
+}
+
+func (s fakeStore) RenameNode(curNode *graph.Node, newID string) error {
+	renamed := *curNode
+	renamed.ID = newID
+	return s.record("RenameNode", []*graph.Node{curNode, &renamed}, nil)
+}
+
+func (s fakeStore) UpdateNodes(nodes []*graph.Node) error {
+	return s.record("UpdateNodes", nodes, nil)
+}
+
+func (s fakeStore) ReplaceNodes(nodes, removed []*graph.Node) error {
+	return s.record("ReplaceNodes", nodes, removed)
+}
+
+func (s fakeStore) GetEdges() ([]*graph.Edge, error) { return nil, nil }
+
+func (s fakeStore) InsertEdge(edge *graph.Edge) error { return s.record("InsertEdge", nil, nil) }
+
+func (s fakeStore) DeleteEdge(edge *graph.Edge) error { return s.record("DeleteEdge", nil, nil) }
+
+func (s fakeStore) WithEvent(e *graph.Event) storage.Persister {
+	return fakeStore{fakeLog: s.fakeLog, pending: e}
+}
+
+func (s fakeStore) GetEvents(since int64, limit int) ([]*graph.Event, error) {
+	events := make([]*graph.Event, 0)
+	for _, e := range s.events {
+		if e.Seq > since && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// newTestController serves a graph made of (id, parent id) pairs, given
+// parent first, from a fakeStore whose change log starts with the baseline.
+func newTestController(t *testing.T, pairs ...[2]string) (Controller, *fakeLog) {
+	nodes := make([]*graph.Node, 0, len(pairs))
+	height := make(map[string]int)
+	for i, pair := range pairs {
+		node := graph.NewEmptyNode()
+		node.ID, node.ParID, node.Position = pair[0], pair[1], i
+		if pair[1] != "" {
+			node.Height = height[pair[1]] + 1
+		}
+		height[node.ID] = node.Height
+		nodes = append(nodes, &node)
+	}
+	g, err := graph.Initialize(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &fakeLog{}
+	store := fakeStore{fakeLog: log}
+	if err := store.WithEvent(graph.NewEvent(graph.EventBaseline, actorSystem, &graph.Graph{}, g)).UpdateNodes(nil); err != nil {
+		t.Fatal(err)
+	}
+	log.writes = nil
+	c := Controller{
+		store:    store,
+		validate: validator.New(&validator.Config{TagName: "validate", FieldNameTag: "json"}),
+		g:        graph.NewShared(g),
+	}
+	return c, log
+}
+
+// serve runs the handler and returns the status code of the response.
+func serve(handler core.Handler, req fakeRequest) int {
+	w := httptest.NewRecorder()
+	handler(req)(w)
+	return w.Code
+}
+
+// parents returns the parent of every node of the live graph.
+func parents(c Controller) map[string]string {
+	par := make(map[string]string)
+	for id, node := range c.g.Snapshot().Nodes {
+		par[id] = node.ParID
+	}
+	return par
+}
+
+// expectReplayed checks that the change log rebuilds the live graph.
+func expectReplayed(g *GomegaWithT, c Controller, log *fakeLog) {
+	replayed, err := graph.Replay(log.events)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(graph.Compare(replayed, c.g.Snapshot())).To(Equal(graph.Compare(replayed, replayed)))
+}
+
+func TestController_CreateMoveRevert(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c, log := newTestController(t, [2]string{"a", ""}, [2]string{"b", "a"}, [2]string{"c", "a"})
+
+	g.Expect(serve(c.Create, fakeRequest{body: `{"id":"d", "pid":"b"}`})).To(Equal(201))
+	g.Expect(log.writes).To(Equal([]write{{method: "InsertNode", removed: []string{},
+		nodes: []*graph.Tree{{ID: "d", ParID: "b", Height: 2}}}}))
+	g.Expect(log.events[1].Kind).To(Equal(graph.EventCreate))
+
+	g.Expect(serve(c.UpdateParent, fakeRequest{path: map[string]string{pathParamID: "d", pathParanParentID: "c"}})).To(Equal(201))
+	g.Expect(log.writes[1].method).To(Equal("UpdateParent"))
+	g.Expect(parents(c)).To(HaveKeyWithValue("d", "c"))
+
+	// Undo the move, d goes back under b
+	log.writes = nil
+	g.Expect(serve(c.Revert, fakeRequest{path: map[string]string{pathParamChangeID: "3"}})).To(Equal(200))
+	g.Expect(log.writes).To(Equal([]write{{method: "ReplaceNodes", removed: []string{},
+		nodes: []*graph.Tree{{ID: "d", ParID: "b", Height: 2}}}}))
+	g.Expect(log.events[3].Kind).To(Equal(graph.EventRevert))
+	g.Expect(log.events[3].Reverts).To(Equal(int64(3)))
+	g.Expect(parents(c)).To(HaveKeyWithValue("d", "b"))
+	expectReplayed(g, c, log)
+
+	// Undo the create, d is removed
+	log.writes = nil
+	g.Expect(serve(c.Revert, fakeRequest{path: map[string]string{pathParamChangeID: "2"}})).To(Equal(200))
+	g.Expect(log.writes).To(Equal([]write{{method: "ReplaceNodes", nodes: []*graph.Tree{}, removed: []string{"d"}}}))
+	g.Expect(parents(c)).NotTo(HaveKey("d"))
+	expectReplayed(g, c, log)
+
+	// The create is gone already
+	g.Expect(serve(c.Revert, fakeRequest{path: map[string]string{pathParamChangeID: "2"}})).To(Equal(409))
+	g.Expect(serve(c.Revert, fakeRequest{path: map[string]string{pathParamChangeID: "9"}})).To(Equal(404))
+}
+
+func TestController_UpdateParent_UnderDescendant(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c, log := newTestController(t, [2]string{"a", ""}, [2]string{"b", "a"}, [2]string{"d", "b"}, [2]string{"g", "d"})
+
+	// d is lifted under a, then b goes under d
+	g.Expect(serve(c.UpdateParent, fakeRequest{path: map[string]string{pathParamID: "b", pathParanParentID: "d"}})).To(Equal(201))
+	live := c.g.Snapshot()
+	g.Expect(live.Validate()).To(BeEmpty())
+	g.Expect(log.writes).To(HaveLen(1))
+	cur, target := log.writes[0].nodes[0], log.writes[0].nodes[1]
+	g.Expect(cur.Height).To(Equal(1), "b is handed over as it was")
+	g.Expect(target.Height).To(Equal(1), "d is handed over as it is after the move")
+	g.Expect(target.Height+1).To(Equal(live.Nodes["b"].Height), "the stored height of b")
+	g.Expect(live.Nodes["g"].Height).To(Equal(2))
+}
+
+func TestController_DeleteRevert(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c, log := newTestController(t, [2]string{"a", ""}, [2]string{"b", "a"}, [2]string{"c", "a"}, [2]string{"d", "b"})
+
+	g.Expect(serve(c.Delete, fakeRequest{path: map[string]string{pathParamID: "b"},
+		query: map[string]string{queryParamStrat: string(graph.DeleteCascade)}})).To(Equal(200))
+	g.Expect(log.writes).To(Equal([]write{{method: "DeleteNode", nodes: []*graph.Tree{}, removed: []string{"b", "d"}}}))
+	g.Expect(parents(c)).To(Equal(map[string]string{"a": "", "c": "a"}))
+
+	// Both come back, with their place among the siblings
+	log.writes = nil
+	g.Expect(serve(c.Revert, fakeRequest{path: map[string]string{pathParamChangeID: "2"}})).To(Equal(200))
+	g.Expect(log.writes).To(Equal([]write{{method: "ReplaceNodes", removed: []string{}, nodes: []*graph.Tree{
+		{ID: "b", ParID: "a", Height: 1, Position: 1, DirectReports: 1, TotalReports: 1},
+		{ID: "d", ParID: "b", Height: 2, Position: 3},
+	}}}))
+	g.Expect(parents(c)).To(Equal(map[string]string{"a": "", "b": "a", "c": "a", "d": "b"}))
+	expectReplayed(g, c, log)
+}
+
+func TestController_RenameRevert(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c, log := newTestController(t, [2]string{"a", ""}, [2]string{"b", "a"}, [2]string{"d", "b"})
+
+	g.Expect(serve(c.RenameNode, fakeRequest{path: map[string]string{pathParamID: "b", pathParamNewID: "bob"}})).To(Equal(200))
+	g.Expect(parents(c)).To(Equal(map[string]string{"a": "", "bob": "a", "d": "bob"}))
+
+	// The rename is undone by renaming back, not by replacing nodes
+	log.writes = nil
+	g.Expect(serve(c.Revert, fakeRequest{path: map[string]string{pathParamChangeID: "2"}})).To(Equal(200))
+	g.Expect(log.writes).To(HaveLen(1))
+	g.Expect(log.writes[0].method).To(Equal("RenameNode"))
+	g.Expect(log.writes[0].nodes[0].ID).To(Equal("bob"))
+	g.Expect(log.writes[0].nodes[1].ID).To(Equal("b"))
+	g.Expect(log.events[2].Rename).To(Equal(&graph.Rename{From: "bob", To: "b"}))
+	g.Expect(parents(c)).To(Equal(map[string]string{"a": "", "b": "a", "d": "b"}))
+	expectReplayed(g, c, log)
+}
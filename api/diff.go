@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const responseKeyDiff = "diff"
+
+// DiffExport compares an export of the hierarchy, given as the body in the
+// 'format' of the query param (csv or json), with the live hierarchy.
+func (c Controller) DiffExport(req core.Request) core.ResponseWriter {
+	format, _ := req.QueryParam(queryParamFormat)
+	before, err := graphFromExport(format, req.Body())
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).
+		Data(responseKeyDiff, graph.Compare(before, c.g.Snapshot())).Writer
+}
+
+// DiffAsOf compares the hierarchy as it was at the time given by the 'as_of'
+// query param with the live hierarchy.
+func (c Controller) DiffAsOf(req core.Request) core.ResponseWriter {
+	if _, ok := req.QueryParam(queryParamAsOf); !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("%s not found in request", queryParamAsOf)).Writer
+	}
+	before, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).
+		Data(responseKeyDiff, graph.Compare(before, c.g.Snapshot())).Writer
+}
+
+// DiffFiles compares two exports of the hierarchy. It is meant for the command line.
+func DiffFiles(format string, before, after io.Reader) (*graph.Diff, error) {
+	gBefore, err := graphFromExport(format, before)
+	if err != nil {
+		return nil, err
+	}
+	gAfter, err := graphFromExport(format, after)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Compare(gBefore, gAfter), nil
+}
+
+func graphFromExport(format string, r io.Reader) (*graph.Graph, error) {
+	nodes, err := parseImport(format, r)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Initialize(nodes)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	pathParamType    = "type"
+	queryParamType   = "type"
+	responseKeyEdge  = "edge"
+	responseKeyOut   = "out"
+	responseKeyIn    = "in"
+	errorMissingPath = "id, other or type not found in request"
+)
+
+// CreateEdge adds a typed secondary reporting line, 'from' reports to 'to'.
+// Edges that would close a cycle among edges of the same type are rejected.
+func (c Controller) CreateEdge(req core.Request) core.ResponseWriter {
+	edge := &graph.Edge{}
+	if err := req.JSON(edge); err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	if err := c.validate.Struct(edge); err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+
+	err := c.g.Update(func(g *graph.Graph) error {
+		if err := g.CheckAddEdge(edge); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+		if err := c.store.WithEvent(graph.NewEdgeEvent(graph.EventEdgeAdd, actor(req), edge)).InsertEdge(edge); err != nil {
+			return err
+		}
+		return g.AddEdge(edge)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyEdge, edge).Writer
+}
+
+// DeleteEdge removes a secondary reporting line.
+func (c Controller) DeleteEdge(req core.Request) core.ResponseWriter {
+	from, okFrom := req.PathParam(pathParamID)
+	to, okTo := req.PathParam(pathParamOtherID)
+	typ, okType := req.PathParam(pathParamType)
+	if !okFrom || !okTo || !okType {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorMissingPath).Writer
+	}
+	edge := &graph.Edge{From: from, To: to, Type: typ}
+
+	err := c.g.Update(func(g *graph.Graph) error {
+		if !hasEdge(g.EdgesFrom(from, typ), to) {
+			return statusError{http.StatusNotFound, fmt.Errorf("edge %s -> %s of type %s not found", from, to, typ)}
+		}
+		if err := c.store.WithEvent(graph.NewEdgeEvent(graph.EventEdgeRemove, actor(req), edge)).DeleteEdge(edge); err != nil {
+			return err
+		}
+		return g.RemoveEdge(from, to, typ)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyEdge, edge).Writer
+}
+
+// GetEdges returns the secondary edges of a given node. 'out' are the lines
+// where the node is the report, 'in' where it is the manager. The optional
+// 'type' query param limits them to a single type.
+func (c Controller) GetEdges(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	if !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	typ, _ := req.QueryParam(queryParamType)
+	g := c.g.Snapshot()
+	if _, ok := g.Nodes[id]; !ok {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid id %s", id)).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).
+		Data(responseKeyOut, g.EdgesFrom(id, typ)).
+		Data(responseKeyIn, g.EdgesTo(id, typ)).Writer
+}
+
+func hasEdge(edges []*graph.Edge, to string) bool {
+	for _, e := range edges {
+		if e.To == to {
+			return true
+		}
+	}
+	return false
+}
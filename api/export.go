@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+// Export formats, next to FormatCSV and FormatJSON
+const (
+	FormatDOT      = "dot"
+	FormatMermaid  = "mermaid"
+	queryParamRoot = "root"
+)
+
+// Export serializes the hierarchy, or the subtree under the 'root' query param,
+// in the format given by the 'format' query param: dot, mermaid, json or csv.
+// Without 'root' every tree is exported, json only takes a single tree.
+func (c Controller) Export(req core.Request) core.ResponseWriter {
+	format, _ := req.QueryParam(queryParamFormat)
+	rootID, _ := req.QueryParam(queryParamRoot)
+	g := c.g.Snapshot()
+
+	var buf bytes.Buffer
+	var err error
+	mediaType := core.MediaTypeText
+	switch format {
+	case FormatJSON:
+		// A nested tree has a single top, name the tree if there are more
+		if rootID == "" {
+			root, err := g.TreeRoot("")
+			if err != nil {
+				return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+					Data(responseKeyErrors, err.Error()).Writer
+			}
+			rootID = root.ID
+		}
+		tree, err := g.Subtree(rootID, graph.NoDepthLimit)
+		if err != nil {
+			return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+				Data(responseKeyErrors, err.Error()).Writer
+		}
+		return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, tree).Writer
+	case FormatCSV:
+		mediaType = core.MediaTypeCSV
+		err = g.WriteCSV(&buf, rootID)
+	case FormatDOT:
+		err = g.WriteDOT(&buf, rootID)
+	case FormatMermaid:
+		err = g.WriteMermaid(&buf, rootID)
+	default:
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", queryParamFormat, format)).Writer
+	}
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, mediaType).Body(buf.Bytes()).Writer
+}
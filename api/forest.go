@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	queryParamTree   = "tree"
+	responseKeyTrees = "trees"
+)
+
+// GetTrees returns the root of every tree, ordered by id. A tree is named
+// after it's root, the name is what the 'tree' query param takes.
+func (c Controller) GetTrees(req core.Request) core.ResponseWriter {
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyTrees, v.nodes(g.Trees())).Writer
+}
+
+// GetTreeOf returns the root of the tree a given node is in.
+func (c Controller) GetTreeOf(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	if !ok {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	v, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	root, err := g.TreeOf(id)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, v.node(root)).Writer
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/storage"
+	"github.com/DeshErBojhaa/tradeshift/storage/mysql"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+	"gopkg.in/go-playground/validator.v8"
+)
+
+// Import formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// errInvalidRows is returned when an import is rejected because of it's rows.
+var errInvalidRows = fmt.Errorf("import rejected, no rows were imported")
+
+// Import creates a whole hierarchy in one go. The body is CSV or nested JSON,
+// chosen with the 'format' query param. Either every row is imported or none
+// of them, in which case the errors of each invalid row are returned.
+func (c Controller) Import(req core.Request) core.ResponseWriter {
+	format, _ := req.QueryParam(queryParamFormat)
+	rows, err := parseImport(format, req.Body())
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+
+	var imported []*graph.Node
+	var rowErrors []graph.RowError
+	err = c.g.Update(func(g *graph.Graph) error {
+		imported, rowErrors, err = importNodes(c.store, c.validate, g, rows, actor(req))
+		return err
+	})
+	if err == errInvalidRows {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, rowErrors).Writer
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusCreated, core.MediaTypeJSON).Data(responseKeyNode, flatten(imported)).Writer
+}
+
+// ImportFile imports a hierarchy straight into the persistent layer. It is
+// meant for the command line, while the API server is not running.
+func ImportFile(connString, format string, r io.Reader, v *validator.Validate) (int, []graph.RowError, error) {
+	rows, err := parseImport(format, r)
+	if err != nil {
+		return 0, nil, err
+	}
+	db, err := mysql.NewMySQLStore(connString)
+	if err != nil {
+		return 0, nil, err
+	}
+	nodes, err := db.GetNodes()
+	if err != nil {
+		return 0, nil, err
+	}
+	gp, err := graph.Initialize(nodes)
+	if err != nil {
+		return 0, nil, err
+	}
+	if gp.Edges, err = db.GetEdges(); err != nil {
+		return 0, nil, err
+	}
+	if err := initEventLog(db, gp); err != nil {
+		return 0, nil, err
+	}
+	imported, rowErrors, err := importNodes(db, v, gp, rows, actorCLI)
+	return len(imported), rowErrors, err
+}
+
+func parseImport(format string, r io.Reader) ([]*graph.Node, error) {
+	switch format {
+	case FormatCSV:
+		return graph.ParseCSV(r)
+	case FormatJSON:
+		return graph.ParseNestedJSON(r)
+	default:
+		return nil, fmt.Errorf("invalid %s: %s", queryParamFormat, format)
+	}
+}
+
+// importNodes validates the rows against 'g', emplaces them into 'g' and then
+// inserts them into the store in one transaction, as a single change by 'actor'.
+func importNodes(store storage.Persister, v *validator.Validate, g *graph.Graph, rows []*graph.Node, actor string) ([]*graph.Node, []graph.RowError, error) {
+	ordered, rowErrors := g.PlanImport(rows)
+	for i, row := range rows {
+		if err := v.Struct(row.Attributes); err != nil {
+			rowErrors = append(rowErrors, graph.RowError{Row: i + 1, ID: row.ID, Error: err.Error()})
+		}
+	}
+	if len(rowErrors) > 0 {
+		sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+		return nil, rowErrors, errInvalidRows
+	}
+
+	before := g.Clone()
+	for _, node := range ordered {
+		if err := g.EmplaceNode(node); err != nil {
+			return nil, nil, err
+		}
+	}
+	ev := graph.NewEvent(graph.EventImport, actor, before, g)
+	if err := store.WithEvent(ev).InsertNodes(ordered); err != nil {
+		return nil, nil, err
+	}
+	return ordered, nil, nil
+}
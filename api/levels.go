@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	pathParamDepth  = "depth"
+	pathParamLevels = "levels"
+)
+
+// GetAtDepth returns every node at the depth given by the path param, the
+// roots being at depth 0. The optional 'tree' query param limits the nodes to
+// a single tree.
+func (c Controller) GetAtDepth(req core.Request) core.ResponseWriter {
+	v, _ := req.PathParam(pathParamDepth)
+	depth, err := strconv.Atoi(v)
+	if err != nil || depth < 0 {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", pathParamDepth, v)).Writer
+	}
+	view, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	nodes := g.AtDepth(depth)
+	if name, ok := req.QueryParam(queryParamTree); ok {
+		root, err := g.TreeRoot(name)
+		if err != nil {
+			return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+				Data(responseKeyErrors, err.Error()).Writer
+		}
+		if nodes, err = g.LevelBelow(root.ID, depth); err != nil {
+			return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+				Data(responseKeyErrors, err.Error()).Writer
+		}
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, view.nodes(nodes)).Writer
+}
+
+// GetLevelBelow returns the nodes exactly 'levels' levels below a given node,
+// e.g. the skip-level reports with 2.
+func (c Controller) GetLevelBelow(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	v, _ := req.PathParam(pathParamLevels)
+	k, err := strconv.Atoi(v)
+	if !ok || err != nil || k < 0 {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", pathParamLevels, v)).Writer
+	}
+	view, err := viewOf(req)
+	if err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	nodes, err := g.LevelBelow(id, k)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, view.nodes(nodes)).Writer
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	queryParamTop      = "top"
+	defaultTop         = 10
+	responseKeyMetrics = "metrics"
+)
+
+// GetTreeMetrics returns node count, max depth, fan-out histogram, leaf count
+// and the largest spans of control of the hierarchy, or of the subtree under
+// the optional 'id' path param. 'top' limits the number of largest spans.
+// Without 'id' the metrics are of the tree named by the 'tree' query param,
+// which can be left out if there is a single tree.
+func (c Controller) GetTreeMetrics(req core.Request) core.ResponseWriter {
+	id, _ := req.PathParam(pathParamID)
+	top := defaultTop
+	if v, ok := req.QueryParam(queryParamTop); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+				Data(responseKeyErrors, fmt.Sprintf("invalid %s: %s", queryParamTop, v)).Writer
+		}
+		top = n
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	if id == "" {
+		name, _ := req.QueryParam(queryParamTree)
+		root, err := g.TreeRoot(name)
+		if err != nil {
+			return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+				Data(responseKeyErrors, err.Error()).Writer
+		}
+		id = root.ID
+	}
+	m, err := g.Metrics(id, top)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyMetrics, m).Writer
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	pathParamSibling  = "sibling"
+	queryParamBefore  = "before"
+	queryParamAfter   = "after"
+	errorMissingPlace = "id or sibling not found in request"
+)
+
+// PlaceBefore moves a node right before one of it's siblings.
+func (c Controller) PlaceBefore(req core.Request) core.ResponseWriter {
+	return c.place(req, false)
+}
+
+// PlaceAfter moves a node right after one of it's siblings.
+func (c Controller) PlaceAfter(req core.Request) core.ResponseWriter {
+	return c.place(req, true)
+}
+
+func (c Controller) place(req core.Request, after bool) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	sibling, okSibling := req.PathParam(pathParamSibling)
+	if !ok || !okSibling {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorMissingPlace).Writer
+	}
+
+	var children []*graph.Node
+	err := c.g.Update(func(g *graph.Graph) error {
+		if err := g.CheckPlaceNode(id, sibling); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+		changed, err := g.PlaceNode(id, sibling, after)
+		if err != nil {
+			return err
+		}
+		if children, err = g.GetChildren(g.Nodes[id].ParID); err != nil {
+			return err
+		}
+		return c.logged(req, graph.EventReorder, g).UpdateNodes(changed)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, flatten(children)).Writer
+}
+
+// OrderChildren sets the order of the children of a given node. The body is
+// the list of child ids in the new order, every child listed once.
+func (c Controller) OrderChildren(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	var order []string
+	if err := req.JSON(&order); !ok || err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+
+	var children []*graph.Node
+	err := c.g.Update(func(g *graph.Graph) error {
+		if err := g.CheckOrderChildren(id, order); err != nil {
+			return statusError{http.StatusBadRequest, err}
+		}
+		changed, err := g.OrderChildren(id, order)
+		if err != nil {
+			return err
+		}
+		if children, err = g.GetChildren(id); err != nil {
+			return err
+		}
+		return c.logged(req, graph.EventReorder, g).UpdateNodes(changed)
+	})
+	if err != nil {
+		return errorResponse(err)
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyNode, flatten(children)).Writer
+}
+
+// placement returns the sibling given by the 'before' or 'after' query param.
+func placement(req core.Request) (sibling string, after, ok bool) {
+	if sibling, ok = req.QueryParam(queryParamAfter); ok {
+		return sibling, true, true
+	}
+	sibling, ok = req.QueryParam(queryParamBefore)
+	return sibling, false, ok
+}
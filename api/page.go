@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	queryParamSort   = "sort"
+	queryParamOrder  = "order"
+	orderAsc         = "asc"
+	orderDesc        = "desc"
+	queryParamName   = "name"
+	queryParamTitle  = "title"
+	queryParamEmail  = "email"
+	queryParamLabel  = "label"
+	queryParamCursor = "cursor"
+	maxPageLimit     = 1000
+	responseKeyNext  = "next"
+)
+
+// childrenQuery reads the sort, filter and paging query params of a children listing.
+func childrenQuery(req core.Request) (graph.ChildrenQuery, error) {
+	q := graph.ChildrenQuery{}
+	q.Sort, _ = req.QueryParam(queryParamSort)
+	switch order, _ := req.QueryParam(queryParamOrder); order {
+	case "", orderAsc:
+	case orderDesc:
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("invalid %s: %s", queryParamOrder, order)
+	}
+	q.Name, _ = req.QueryParam(queryParamName)
+	q.Title, _ = req.QueryParam(queryParamTitle)
+	q.Email, _ = req.QueryParam(queryParamEmail)
+	q.Label, _ = req.QueryParam(queryParamLabel)
+	q.Cursor, _ = req.QueryParam(queryParamCursor)
+	if v, ok := req.QueryParam(queryParamLimit); ok {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return q, fmt.Errorf("%s must be between 1 and %d", queryParamLimit, maxPageLimit)
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
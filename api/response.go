@@ -13,6 +13,7 @@ type Response struct {
 	statusCode int
 	mediaType  string
 	data       map[string]interface{}
+	body       []byte
 }
 
 // NewResponse ...
@@ -30,11 +31,22 @@ func (r *Response) Data(key string, value interface{}) *Response {
 	return r
 }
 
+// Body sets a raw body, which is written as is instead of the data.
+func (r *Response) Body(body []byte) *Response {
+	r.body = body
+	return r
+}
+
 // Writer ...
 func (r *Response) Writer(w http.ResponseWriter) {
 	// Write the header first (important!)
 	r.writeHeader(w)
 
+	if r.body != nil {
+		r.writeBody(w, r.body)
+		return
+	}
+
 	// If there is no data we are done here
 	if len(r.data) == 0 {
 		return
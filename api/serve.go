@@ -23,20 +23,63 @@ func Serve(listenAddress, connString string, v *validator.Validate) error {
 		log.Fatal(err)
 	}
 
+	for _, problem := range graph.Validate(nodes) {
+		log.Printf("hierarchy problem: %s %s: %s", problem.Kind, problem.ID, problem.Detail)
+	}
+
 	gp, err := graph.Initialize(nodes)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if gp.Edges, err = db.GetEdges(); err != nil {
+		log.Fatal(err)
+	}
+	if err := initEventLog(db, gp); err != nil {
+		log.Fatal(err)
+	}
 	controller := Controller{
 		store:    db,
 		validate: v,
-		g:        gp,
+		g:        graph.NewShared(gp),
 	}
 
 	s := webber.NewServer(listenAddress, core.MediaTypeJSON)
 	s.POST("/node/create", controller.Create)
 	s.PUT("/node/{id}/make_parent/{parid}", controller.UpdateParent)
+	s.PATCH("/node/{id}", controller.UpdateAttributes)
+	s.PUT("/node/{id}/rename/{newid}", controller.RenameNode)
+	s.PUT("/node/{id}/swap/{other}", controller.SwapNodes)
+	s.PUT("/node/{id}/before/{sibling}", controller.PlaceBefore)
+	s.PUT("/node/{id}/after/{sibling}", controller.PlaceAfter)
+	s.PUT("/children/{id}/order", controller.OrderChildren)
+	s.DELETE("/node/{id}", controller.Delete)
 	s.GET("/children/{id}", controller.GetChildren)
+	s.GET("/nodes/{id}/descendants", controller.GetDescendants)
+	s.GET("/descendants/{id}", controller.GetDescendants) // alias of the above
+	s.GET("/ancestors/{id}", controller.GetAncestors)
+	s.GET("/lca/{id}/{other}", controller.GetLowestCommonAncestor)
+	s.GET("/path/{id}/{other}", controller.GetPath)
+	s.GET("/depth/{depth}", controller.GetAtDepth)
+	s.GET("/below/{id}/{levels}", controller.GetLevelBelow)
+	s.GET("/is_ancestor/{id}/{other}", controller.IsAncestor)
+	s.POST("/subtree/{id}/members", controller.SubtreeMembers)
+	s.GET("/trees", controller.GetTrees)
+	s.GET("/tree/{id}", controller.GetTreeOf)
+	s.GET("/metrics/tree", controller.GetTreeMetrics)
+	s.GET("/metrics/tree/{id}", controller.GetTreeMetrics)
+	s.POST("/edge", controller.CreateEdge)
+	s.DELETE("/edge/{id}/{other}/{type}", controller.DeleteEdge)
+	s.GET("/edges/{id}", controller.GetEdges)
+	s.GET("/admin/validate", controller.Validate)
+	s.POST("/admin/repair", controller.Repair)
+	s.POST("/import", controller.Import)
+	s.GET("/export", controller.Export)
+	s.POST("/diff", controller.DiffExport)
+	s.GET("/diff", controller.DiffAsOf)
+	s.GET("/changes", controller.GetChanges)
+	s.GET("/changes/{changeID}", controller.GetChange)
+	s.POST("/changes/{changeID}/revert", controller.Revert)
+	s.GET("/admin/replay", controller.Replay)
 
 	return s.Serve()
 }
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	responseKeyAncestor = "ancestor"
+	responseKeyMembers  = "members"
+)
+
+// IsAncestor tells whether the node given by 'id' is an ancestor of the node
+// given by 'other'.
+func (c Controller) IsAncestor(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	other, okOther := req.PathParam(pathParamOtherID)
+	if !ok || !okOther {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	ancestor, err := g.IsAncestor(id, other)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyAncestor, ancestor).Writer
+}
+
+// SubtreeMembers tells for each id of the body, a JSON list, whether it is in
+// the subtree of a given node.
+func (c Controller) SubtreeMembers(req core.Request) core.ResponseWriter {
+	id, ok := req.PathParam(pathParamID)
+	var ids []string
+	if err := req.JSON(&ids); !ok || err != nil {
+		return NewResponse(http.StatusBadRequest, core.MediaTypeJSON).
+			Data(responseKeyErrors, errorBadBody).Writer
+	}
+	g, errResp := c.graphAsOf(req)
+	if errResp != nil {
+		return errResp
+	}
+	members, err := g.InSubtree(id, ids)
+	if err != nil {
+		return NewResponse(http.StatusNotFound, core.MediaTypeJSON).
+			Data(responseKeyErrors, err.Error()).Writer
+	}
+	return NewResponse(http.StatusOK, core.MediaTypeJSON).Data(responseKeyMembers, members).Writer
+}
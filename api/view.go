@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/webber/core"
+)
+
+const (
+	queryParamFields = "fields"
+	fieldChildren    = "children"
+)
+
+// viewFields are the fields of a node that can be picked with 'fields'.
+var viewFields = map[string]bool{
+	"id": true, "pid": true, "height": true, "position": true, "direct_reports": true, "total_reports": true,
+	"name": true, "title": true, "email": true, "labels": true, "child_ids": true, fieldChildren: true,
+}
+
+// view is the shape nodes are rendered in. With 'format=flat', the default,
+// a node lists it's children by id. With 'format=nested' the children are
+// nested 'max_depth' levels deep, 1 by default. 'fields' keeps only the
+// listed fields of each node, nesting is kept either way. So the size of a
+// response never depends on how many nodes are under the returned ones,
+// unless asked for.
+type view struct {
+	nested bool
+	depth  int
+	fields map[string]bool // nil keeps every field
+}
+
+func viewOf(req core.Request) (view, error) {
+	v := view{depth: 1}
+	switch format, _ := req.QueryParam(queryParamFormat); format {
+	case "", formatFlat:
+	case formatNested:
+		v.nested = true
+	default:
+		return v, fmt.Errorf("invalid %s: %s", queryParamFormat, format)
+	}
+	if s, ok := req.QueryParam(queryParamDepth); ok {
+		depth, err := strconv.Atoi(s)
+		if err != nil || depth < 0 {
+			return v, fmt.Errorf("invalid %s: %s", queryParamDepth, s)
+		}
+		v.depth = depth
+	}
+	if s, ok := req.QueryParam(queryParamFields); ok {
+		v.fields = map[string]bool{fieldChildren: true}
+		for _, field := range strings.Split(s, ",") {
+			field = strings.TrimSpace(field)
+			if !viewFields[field] {
+				return v, fmt.Errorf("invalid field %s", field)
+			}
+			v.fields[field] = true
+		}
+	}
+	return v, nil
+}
+
+// node renders a single node.
+func (v view) node(n *graph.Node) interface{} {
+	return v.project(v.tree(n))
+}
+
+// nodes renders a list of nodes.
+func (v view) nodes(nodes []*graph.Node) interface{} {
+	trees := make([]*graph.Tree, len(nodes))
+	for i, n := range nodes {
+		trees[i] = v.tree(n)
+	}
+	return v.project(trees)
+}
+
+func (v view) tree(n *graph.Node) *graph.Tree {
+	if v.nested {
+		return n.Tree(v.depth)
+	}
+	return n.Shallow()
+}
+
+// project drops the fields that were not asked for from trees of any shape.
+func (v view) project(trees interface{}) interface{} {
+	if v.fields == nil {
+		return trees
+	}
+	b, err := json.Marshal(trees)
+	if err != nil {
+		return trees
+	}
+	var raw interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return trees
+	}
+	v.keep(raw)
+	return raw
+}
+
+func (v view) keep(raw interface{}) {
+	switch x := raw.(type) {
+	case []interface{}:
+		for _, item := range x {
+			v.keep(item)
+		}
+	case map[string]interface{}:
+		for field, value := range x {
+			if !v.fields[field] {
+				delete(x, field)
+			}
+			if field == fieldChildren {
+				v.keep(value)
+			}
+		}
+	}
+}
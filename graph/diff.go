@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"sort"
+)
+
+// Move is a node that got a new parent.
+type Move struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// AttributeChange is a node whose attributes changed.
+type AttributeChange struct {
+	ID     string     `json:"id"`
+	Before Attributes `json:"before"`
+	After  Attributes `json:"after"`
+}
+
+// Diff is the structured difference between two versions of the hierarchy.
+// Nodes whose height changed only because an ancestor moved are not moves.
+type Diff struct {
+	Added   []*Tree           `json:"added"`
+	Removed []*Tree           `json:"removed"`
+	Moved   []Move            `json:"moved"`
+	Changed []AttributeChange `json:"changed"`
+}
+
+// Compare returns what changed from 'before' to 'after'. Every list of the
+// diff is ordered by node id.
+func Compare(before, after *Graph) *Diff {
+	d := &Diff{
+		Added:   make([]*Tree, 0),
+		Removed: make([]*Tree, 0),
+		Moved:   make([]Move, 0),
+		Changed: make([]AttributeChange, 0),
+	}
+	for _, id := range nodeIDs(after) {
+		node := after.Nodes[id]
+		old, ok := before.Nodes[id]
+		if !ok {
+			d.Added = append(d.Added, node.Flat())
+			continue
+		}
+		if old.ParID != node.ParID {
+			d.Moved = append(d.Moved, Move{ID: id, From: old.ParID, To: node.ParID})
+		}
+		if !attributesEqual(old.Attributes, node.Attributes) {
+			d.Changed = append(d.Changed, AttributeChange{ID: id, Before: old.Attributes, After: node.Attributes})
+		}
+	}
+	for _, id := range nodeIDs(before) {
+		if _, ok := after.Nodes[id]; !ok {
+			d.Removed = append(d.Removed, before.Nodes[id].Flat())
+		}
+	}
+	return d
+}
+
+// Empty tells if nothing changed.
+func (d *Diff) Empty() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Moved)+len(d.Changed) == 0
+}
+
+func attributesEqual(a, b Attributes) bool {
+	if a.Name != b.Name || a.Title != b.Title || a.Email != b.Email || len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for k, v := range a.Labels {
+		if w, ok := b.Labels[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeIDs(g *Graph) []string {
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
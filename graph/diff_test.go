@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCompare(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	before := sampleGraph(t)
+	after := before.Clone()
+	g.Expect(after.MoveSubtree("d", "c")).To(Succeed())
+	_, err := after.RemoveNode("e", DeleteRefuse)
+	g.Expect(err).NotTo(HaveOccurred())
+	h := NewEmptyNode()
+	h.ID, h.ParID, h.Height = "h", "f", 3
+	g.Expect(after.EmplaceNode(&h)).To(Succeed())
+	g.Expect(after.UpdateAttributes("a", Attributes{Name: "Alice"})).To(Succeed())
+
+	d := Compare(before, after)
+	g.Expect(d.Added).To(Equal([]*Tree{{ID: "h", ParID: "f", Height: 3}}))
+	g.Expect(d.Removed).To(Equal([]*Tree{{ID: "e", ParID: "b", Height: 2, Position: 1}}))
+	g.Expect(d.Moved).To(Equal([]Move{{ID: "d", From: "b", To: "c"}}), "g only changed height")
+	g.Expect(d.Changed).To(Equal([]AttributeChange{{ID: "a", After: Attributes{Name: "Alice"}}}))
+
+	g.Expect(Compare(before, before).Empty()).To(BeTrue())
+}
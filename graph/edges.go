@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEdgeCycle triggers when an edge would close a cycle among edges of it's type
+var ErrEdgeCycle = errors.New("edge would form a cycle")
+
+// ErrDuplicateEdge triggers when the same edge is added twice
+var ErrDuplicateEdge = errors.New("edge already exists")
+
+// Edge is a secondary reporting line, e.g. a dotted line or a project lead.
+// 'From' reports to 'To'. Edges live next to the primary tree and have no
+// effect on ParID, Children or Height.
+type Edge struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+	Type string `json:"type" validate:"required"`
+}
+
+// CheckAddEdge validates that the edge can be added. Both nodes must exist and
+// the edge must not close a cycle among the edges of the same type.
+func (g *Graph) CheckAddEdge(e *Edge) error {
+	for _, id := range []string{e.From, e.To} {
+		if _, ok := g.Nodes[id]; !ok {
+			return fmt.Errorf("invalid id %s", id)
+		}
+	}
+	if e.From == e.To {
+		return ErrEdgeCycle
+	}
+	next := make(map[string][]string)
+	for _, edge := range g.Edges {
+		if edge.Type != e.Type {
+			continue
+		}
+		if edge.From == e.From && edge.To == e.To {
+			return ErrDuplicateEdge
+		}
+		next[edge.From] = append(next[edge.From], edge.To)
+	}
+	// A cycle is closed if 'From' can already be reached from 'To'
+	seen := map[string]bool{e.To: true}
+	stack := []string{e.To}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, id := range next[cur] {
+			if id == e.From {
+				return ErrEdgeCycle
+			}
+			if !seen[id] {
+				seen[id] = true
+				stack = append(stack, id)
+			}
+		}
+	}
+	return nil
+}
+
+// AddEdge adds a secondary edge to the graph.
+func (g *Graph) AddEdge(e *Edge) error {
+	if err := g.CheckAddEdge(e); err != nil {
+		return err
+	}
+	g.Edges = append(g.Edges, e)
+	return nil
+}
+
+// RemoveEdge removes a secondary edge from the graph.
+func (g *Graph) RemoveEdge(from, to, typ string) error {
+	for i, edge := range g.Edges {
+		if edge.From == from && edge.To == to && edge.Type == typ {
+			g.Edges = append(g.Edges[:i:i], g.Edges[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("edge %s -> %s of type %s not found", from, to, typ)
+}
+
+// EdgesFrom returns the secondary edges where node 'id' is the report. An
+// empty type matches every type.
+func (g *Graph) EdgesFrom(id, typ string) []*Edge {
+	return g.filterEdges(func(e *Edge) bool { return e.From == id && (typ == "" || e.Type == typ) })
+}
+
+// EdgesTo returns the secondary edges where node 'id' is the manager. An
+// empty type matches every type.
+func (g *Graph) EdgesTo(id, typ string) []*Edge {
+	return g.filterEdges(func(e *Edge) bool { return e.To == id && (typ == "" || e.Type == typ) })
+}
+
+// removeEdgesOf drops every edge that touches one of the given nodes.
+func (g *Graph) removeEdgesOf(ids map[string]bool) {
+	g.Edges = g.filterEdges(func(e *Edge) bool { return !ids[e.From] && !ids[e.To] })
+}
+
+func (g *Graph) filterEdges(keep func(e *Edge) bool) []*Edge {
+	edges := make([]*Edge, 0)
+	for _, e := range g.Edges {
+		if keep(e) {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
@@ -0,0 +1,54 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_AddEdge(t *testing.T) {
+	tests := []struct {
+		name string
+		edge Edge
+		want error
+	}{
+		{name: "Valid", edge: Edge{From: "g", To: "c", Type: "dotted-line"}},
+		{name: "OtherTypeCycle", edge: Edge{From: "c", To: "f", Type: "project-lead"}},
+		{name: "Cycle", edge: Edge{From: "c", To: "f", Type: "dotted-line"}, want: ErrEdgeCycle},
+		{name: "Self", edge: Edge{From: "c", To: "c", Type: "dotted-line"}, want: ErrEdgeCycle},
+		{name: "Duplicate", edge: Edge{From: "f", To: "d", Type: "dotted-line"}, want: ErrDuplicateEdge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			gp := sampleGraph(t)
+			// f -> d -> c among dotted lines, g -> c among project leads
+			g.Expect(gp.AddEdge(&Edge{From: "f", To: "d", Type: "dotted-line"})).To(Succeed())
+			g.Expect(gp.AddEdge(&Edge{From: "d", To: "c", Type: "dotted-line"})).To(Succeed())
+			g.Expect(gp.AddEdge(&Edge{From: "g", To: "c", Type: "project-lead"})).To(Succeed())
+
+			err := gp.AddEdge(&tt.edge)
+			if tt.want == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_EdgesOfRemovedNode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gp := sampleGraph(t)
+	g.Expect(gp.AddEdge(&Edge{From: "f", To: "d", Type: "dotted-line"})).To(Succeed())
+	g.Expect(gp.AddEdge(&Edge{From: "e", To: "c", Type: "dotted-line"})).To(Succeed())
+	g.Expect(gp.EdgesTo("d", "")).To(HaveLen(1))
+	g.Expect(gp.EdgesFrom("f", "project-lead")).To(BeEmpty())
+	g.Expect(gp.Nodes["d"].Height).To(Equal(2), "edges do not change the primary tree")
+
+	_, err := gp.RemoveNode("b", DeleteCascade)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(gp.Edges).To(BeEmpty())
+}
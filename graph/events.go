@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventKind names the mutation recorded by an Event.
+type EventKind string
+
+// Kinds of mutation
+const (
+	EventBaseline     EventKind = "baseline"
+	EventCreate       EventKind = "create"
+	EventUpdateParent EventKind = "update_parent"
+	EventMoveSubtree  EventKind = "move_subtree"
+	EventDelete       EventKind = "delete"
+	EventAttributes   EventKind = "attributes"
+	EventImport       EventKind = "import"
+	EventRepair       EventKind = "repair"
+	EventEdgeAdd      EventKind = "edge_add"
+	EventEdgeRemove   EventKind = "edge_remove"
+	EventRevert       EventKind = "revert"
+	EventRename       EventKind = "rename"
+	EventSwap         EventKind = "swap"
+	EventReorder      EventKind = "reorder"
+)
+
+// Event records one mutation of the hierarchy. 'Before' holds every node the
+// mutation touched as it was, 'After' as it became. A node missing from
+// 'Before' was created, a node missing from 'After' was removed. Edge events
+// carry the edge instead, renames the old and new id of the node. Seq orders
+// the events of a change log. A revert refers to the event it undoes with Reverts.
+type Event struct {
+	Seq     int64     `json:"seq"`
+	Reverts int64     `json:"reverts,omitempty"`
+	Kind    EventKind `json:"kind"`
+	Actor   string    `json:"actor"`
+	At      time.Time `json:"at"`
+	Before  []*Tree   `json:"before"`
+	After   []*Tree   `json:"after"`
+	Edge    *Edge     `json:"edge,omitempty"`
+	Rename  *Rename   `json:"rename,omitempty"`
+}
+
+// NewEvent records the nodes that differ between 'before' and 'after', the
+// versions of the graph right before and right after a mutation. It compares
+// every node, which costs no more than the copy Shared makes for the write.
+func NewEvent(kind EventKind, actor string, before, after *Graph) *Event {
+	e := &Event{Kind: kind, Actor: actor, At: time.Now().UTC(), Before: make([]*Tree, 0), After: make([]*Tree, 0)}
+	for _, id := range nodeIDs(after) {
+		node := after.Nodes[id]
+		old, ok := before.Nodes[id]
+		if ok && old.ParID == node.ParID && old.Height == node.Height && old.Position == node.Position && attributesEqual(old.Attributes, node.Attributes) {
+			continue
+		}
+		if ok {
+			e.Before = append(e.Before, old.Flat())
+		}
+		e.After = append(e.After, node.Flat())
+	}
+	for _, id := range nodeIDs(before) {
+		if _, ok := after.Nodes[id]; !ok {
+			e.Before = append(e.Before, before.Nodes[id].Flat())
+		}
+	}
+	return e
+}
+
+// NewEdgeEvent records adding or removing a secondary edge.
+func NewEdgeEvent(kind EventKind, actor string, edge *Edge) *Event {
+	return &Event{Kind: kind, Actor: actor, At: time.Now().UTC(), Before: make([]*Tree, 0), After: make([]*Tree, 0), Edge: edge}
+}
+
+// Replay rebuilds a graph by applying the events in order, starting from an
+// empty graph. The first event is normally the baseline of the log.
+func Replay(events []*Event) (*Graph, error) {
+	state := make(map[string]*Tree)
+	edges := make([]*Edge, 0)
+	for _, e := range events {
+		switch e.Kind {
+		case EventEdgeAdd:
+			edges = append(edges, e.Edge)
+		case EventEdgeRemove:
+			kept := make([]*Edge, 0, len(edges))
+			for _, edge := range edges {
+				if *edge != *e.Edge {
+					kept = append(kept, edge)
+				}
+			}
+			edges = kept
+		default:
+			if err := applyStates(state, e.Before, e.After); err != nil {
+				return nil, fmt.Errorf("event %d: %v", e.Seq, err)
+			}
+			if e.Rename != nil {
+				edges = renameEdges(edges, e.Rename.From, e.Rename.To)
+			}
+		}
+	}
+
+	nodes := make([]*Node, 0, len(state))
+	for _, t := range state {
+		node := NewEmptyNode()
+		node.ID, node.ParID, node.Height, node.Position, node.Attributes = t.ID, t.ParID, t.Height, t.Position, t.Attributes
+		nodes = append(nodes, &node)
+	}
+	g, err := Initialize(nodes)
+	if err != nil {
+		return nil, err
+	}
+	// Edges of removed nodes went away with them
+	g.Edges = edges
+	g.removeEdgesOf(missing(g, edges))
+	return g, nil
+}
+
+// applyStates moves 'state' from the 'from' node states to the 'to' states.
+func applyStates(state map[string]*Tree, from, to []*Tree) error {
+	for _, t := range from {
+		if _, ok := state[t.ID]; !ok {
+			return fmt.Errorf("node %s does not exist", t.ID)
+		}
+		delete(state, t.ID)
+	}
+	for _, t := range to {
+		state[t.ID] = t
+	}
+	return nil
+}
+
+// missing returns the ids referenced by edges that are not in the graph.
+func missing(g *Graph, edges []*Edge) map[string]bool {
+	ids := make(map[string]bool)
+	for _, e := range edges {
+		for _, id := range []string{e.From, e.To} {
+			if _, ok := g.Nodes[id]; !ok {
+				ids[id] = true
+			}
+		}
+	}
+	return ids
+}
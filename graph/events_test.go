@@ -0,0 +1,69 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewEvent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	before := sampleGraph(t)
+	after := before.Clone()
+	g.Expect(after.MoveSubtree("d", "c")).To(Succeed())
+
+	e := NewEvent(EventMoveSubtree, "alice", before, after)
+	g.Expect(e.Actor).To(Equal("alice"))
+	g.Expect(e.Before).To(Equal([]*Tree{{ID: "d", ParID: "b", Height: 2, DirectReports: 1, TotalReports: 1}}), "g only moved along")
+	g.Expect(e.After).To(Equal([]*Tree{{ID: "d", ParID: "c", Height: 2, Position: 1, DirectReports: 1, TotalReports: 1}}), "d goes after f")
+
+	_, err := after.RemoveNode("d", DeleteCascade)
+	g.Expect(err).NotTo(HaveOccurred())
+	e = NewEvent(EventDelete, "alice", before, after)
+	g.Expect(e.After).To(BeEmpty())
+	g.Expect(e.Before).To(Equal([]*Tree{{ID: "d", ParID: "b", Height: 2, DirectReports: 1, TotalReports: 1}, {ID: "g", ParID: "d", Height: 3}}))
+}
+
+func TestReplay(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	live := sampleGraph(t)
+	events := []*Event{NewEvent(EventBaseline, "system", &Graph{}, live)}
+	step := func(kind EventKind, fn func(next *Graph) error) {
+		next := live.Clone()
+		g.Expect(fn(next)).To(Succeed())
+		events = append(events, NewEvent(kind, "alice", live, next))
+		live = next
+	}
+
+	step(EventMoveSubtree, func(next *Graph) error { return next.MoveSubtree("d", "c") })
+	step(EventUpdateParent, func(next *Graph) error { return next.UpdateParent("c", "b") })
+	step(EventAttributes, func(next *Graph) error { return next.UpdateAttributes("a", Attributes{Name: "Alice"}) })
+	step(EventDelete, func(next *Graph) error {
+		_, err := next.RemoveNode("e", DeleteRefuse)
+		return err
+	})
+	step(EventCreate, func(next *Graph) error {
+		h := NewEmptyNode()
+		h.ID, h.ParID, h.Height = "h", "f", next.Nodes["f"].Height+1
+		return next.EmplaceNode(&h)
+	})
+	edge := &Edge{From: "h", To: "b", Type: "dotted-line"}
+	g.Expect(live.AddEdge(edge)).To(Succeed())
+	events = append(events, NewEdgeEvent(EventEdgeAdd, "alice", edge))
+
+	replayed, err := Replay(events)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(Compare(replayed, live).Empty()).To(BeTrue())
+	g.Expect(replayed.Edges).To(Equal([]*Edge{edge}))
+
+	// A prefix of the log gives the hierarchy as it was back then
+	replayed, err = Replay(events[:2])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(replayed.Nodes["d"].ParID).To(Equal("c"))
+	g.Expect(replayed.Nodes["c"].ParID).To(Equal("a"))
+
+	_, err = Replay(events[1:])
+	g.Expect(err).To(HaveOccurred(), "changes do not apply without the baseline")
+}
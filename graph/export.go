@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// columnHeight is written on export and ignored on import.
+const columnHeight = "height"
+
+// labelPrefix marks a label column whose key would clash with another column.
+const labelPrefix = "label:"
+
+// labelColumn returns the CSV column of label 'key'. Keys that clash with a
+// fixed column, or look prefixed already, get the prefix. See ParseCSV.
+func labelColumn(key string) string {
+	switch column := strings.ToLower(strings.TrimSpace(key)); column {
+	case columnID, columnParID, columnHeight, columnName, columnTitle, columnEmail:
+		return labelPrefix + key
+	default:
+		if strings.HasPrefix(column, labelPrefix) {
+			return labelPrefix + key
+		}
+	}
+	return key
+}
+
+// exportRoot returns the node to export from. An empty id means the only tree.
+func (g *Graph) exportRoot(id string) (*Node, error) {
+	if id == "" {
+		return g.TreeRoot("")
+	}
+	node, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	return node, nil
+}
+
+// exportNodes returns the subtree under 'id' in pre order. An empty id means
+// every tree, one after the other.
+func (g *Graph) exportNodes(id string) ([]*Node, error) {
+	if id != "" {
+		root, err := g.exportRoot(id)
+		if err != nil {
+			return nil, err
+		}
+		return preOrder(root), nil
+	}
+	if len(g.Roots) == 0 {
+		return nil, fmt.Errorf("graph is empty")
+	}
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, root := range g.Trees() {
+		nodes = append(nodes, preOrder(root)...)
+	}
+	return nodes, nil
+}
+
+// exportEdge tells whether the link of 'node' to it's parent is exported.
+// The top of the export has none.
+func exportEdge(node *Node, id string) bool {
+	return node.ParID != "" && node.ID != id
+}
+
+// preOrder returns the node and all of it's descendants, parents before children.
+func preOrder(node *Node) []*Node {
+	nodes := []*Node{node}
+	for _, child := range sortedChildren(node) {
+		nodes = append(nodes, preOrder(child)...)
+	}
+	return nodes
+}
+
+// WriteDOT writes the subtree under 'id' as a Graphviz digraph. An empty id
+// exports every tree.
+func (g *Graph) WriteDOT(w io.Writer, id string) error {
+	nodes, err := g.exportNodes(id)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph hierarchy {")
+	for _, node := range nodes {
+		fmt.Fprintf(bw, "  %s [label=%s];\n", strconv.Quote(node.ID), strconv.Quote(label(node)))
+	}
+	for _, node := range nodes {
+		if exportEdge(node, id) {
+			fmt.Fprintf(bw, "  %s -> %s;\n", strconv.Quote(node.ParID), strconv.Quote(node.ID))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid writes the subtree under 'id' as a Mermaid flowchart. An empty
+// id exports every tree.
+func (g *Graph) WriteMermaid(w io.Writer, id string) error {
+	nodes, err := g.exportNodes(id)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph TD")
+	// Mermaid ids can not hold arbitrary characters, so nodes are numbered
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		index[node.ID] = i
+		text := strings.Replace(label(node), `"`, "#quot;", -1)
+		text = strings.Replace(text, "\n", "<br/>", -1)
+		fmt.Fprintf(bw, "  n%d[\"%s\"]\n", i, text)
+	}
+	for _, node := range nodes {
+		if exportEdge(node, id) {
+			fmt.Fprintf(bw, "  n%d --> n%d\n", index[node.ParID], index[node.ID])
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteCSV writes the subtree under 'id' as CSV, parents before children. The
+// columns are id, pid, height, name, title, email and one column per label.
+// The output can be imported with ParseCSV. An empty id exports every tree.
+func (g *Graph) WriteCSV(w io.Writer, id string) error {
+	nodes, err := g.exportNodes(id)
+	if err != nil {
+		return err
+	}
+	labelSet := make(map[string]bool)
+	for _, node := range nodes {
+		for k := range node.Labels {
+			labelSet[k] = true
+		}
+	}
+	labels := make([]string, 0, len(labelSet))
+	for k := range labelSet {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+
+	cw := csv.NewWriter(w)
+	header := []string{columnID, columnParID, columnHeight, columnName, columnTitle, columnEmail}
+	for _, k := range labels {
+		header = append(header, labelColumn(k))
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		record := []string{node.ID, node.ParID, strconv.Itoa(node.Height), node.Name, node.Title, node.Email}
+		for _, k := range labels {
+			record = append(record, node.Labels[k])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// label is the text shown for a node in diagrams.
+func label(node *Node) string {
+	parts := []string{node.ID}
+	if node.Name != "" {
+		parts = append(parts, node.Name)
+	}
+	if node.Title != "" {
+		parts = append(parts, node.Title)
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_WriteDOT(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gp := sampleGraph(t)
+	gp.Nodes["d"].Name = `Dan "D"`
+	var buf bytes.Buffer
+	g.Expect(gp.WriteDOT(&buf, "b")).To(Succeed())
+	g.Expect(buf.String()).To(Equal(`digraph hierarchy {
+  "b" [label="b"];
+  "d" [label="d\nDan \"D\""];
+  "g" [label="g"];
+  "e" [label="e"];
+  "b" -> "d";
+  "d" -> "g";
+  "b" -> "e";
+}
+`))
+}
+
+func TestGraph_WriteMermaid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var buf bytes.Buffer
+	g.Expect(sampleGraph(t).WriteMermaid(&buf, "c")).To(Succeed())
+	g.Expect(buf.String()).To(Equal("graph TD\n  n0[\"c\"]\n  n1[\"f\"]\n  n0 --> n1\n"))
+}
+
+func TestGraph_WriteCSV(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gp := sampleGraph(t)
+	gp.Nodes["a"].Labels = map[string]string{"site": "cph"}
+	var buf bytes.Buffer
+	g.Expect(gp.WriteCSV(&buf, "")).To(Succeed())
+
+	// What is exported can be imported again
+	nodes, err := ParseCSV(&buf)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(nodes)).To(Equal([]string{"a", "b", "d", "g", "e", "c", "f"}))
+	g.Expect(nodes[0].Labels).To(Equal(map[string]string{"site": "cph"}))
+	empty, _ := Initialize(nil)
+	_, rowErrors := empty.PlanImport(nodes)
+	g.Expect(rowErrors).To(BeEmpty())
+
+	g.Expect(gp.WriteCSV(&buf, "x")).NotTo(Succeed())
+}
+
+func TestGraph_WriteCSV_LabelColumns(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gp := sampleGraph(t)
+	labels := map[string]string{"id": "1", "pid": "2", "name": "3", "title": "4", "email": "5", "height": "6", "label:x": "7", "site": "cph"}
+	gp.Nodes["a"].Labels = labels
+	gp.Nodes["a"].Name = "Alice"
+	var buf bytes.Buffer
+	g.Expect(gp.WriteCSV(&buf, "")).To(Succeed())
+	g.Expect(buf.String()).To(HavePrefix("id,pid,height,name,title,email,label:email,label:height,label:id,label:label:x,label:name,label:pid,site,label:title\n"))
+
+	nodes, err := ParseCSV(&buf)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(nodes)).To(Equal([]string{"a", "b", "d", "g", "e", "c", "f"}))
+	g.Expect(nodes[0].ParID).To(BeEmpty())
+	g.Expect(nodes[0].Name).To(Equal("Alice"))
+	g.Expect(nodes[0].Labels).To(Equal(labels))
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// isRoot tells whether 'node' is the root of one of the trees.
+func (g *Graph) isRoot(node *Node) bool {
+	return g.Roots[node.ID] == node
+}
+
+// Trees returns the root of every tree of the forest, ordered by id. A tree
+// is named after it's root. There is no name apart from the root id, so
+// renaming the root or swapping it with another node renames the tree.
+func (g *Graph) Trees() []*Node {
+	roots := make([]*Node, 0, len(g.Roots))
+	for _, root := range g.Roots {
+		roots = append(roots, root)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].ID < roots[j].ID })
+	return roots
+}
+
+// TreeRoot returns the root of the tree named 'name'. An empty name picks the
+// only tree, so a graph holding a single tree needs no names.
+func (g *Graph) TreeRoot(name string) (*Node, error) {
+	if name != "" {
+		root, ok := g.Roots[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid tree %s", name)
+		}
+		return root, nil
+	}
+	switch len(g.Roots) {
+	case 0:
+		return nil, fmt.Errorf("graph is empty")
+	case 1:
+		return g.Trees()[0], nil
+	}
+	return nil, fmt.Errorf("graph holds %d trees, name one", len(g.Roots))
+}
+
+// TreeOf returns the root of the tree node 'id' is in.
+func (g *Graph) TreeOf(id string) (*Node, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	for _, root := range g.Roots {
+		if root == curNode || g.isAncestor(root, curNode) {
+			return root, nil
+		}
+	}
+	return nil, fmt.Errorf("%s does not lead to a root", id)
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// sampleForest is sampleGraph next to a second tree, x with y under it.
+func sampleForest(t *testing.T) *Graph {
+	gr := sampleGraph(t)
+	for _, pair := range [][2]string{{"x", ""}, {"y", "x"}} {
+		node := NewEmptyNode()
+		node.ID, node.ParID = pair[0], pair[1]
+		if parNode, ok := gr.Nodes[node.ParID]; ok {
+			node.Height = parNode.Height + 1
+		}
+		if err := gr.EmplaceNode(&node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return gr
+}
+
+func TestGraph_TreeRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		graph   func(t *testing.T) *Graph
+		tree    string
+		want    string
+		wantErr bool
+	}{
+		{name: "only tree", graph: sampleGraph, want: "a"},
+		{name: "named", graph: sampleForest, tree: "x", want: "x"},
+		{name: "unnamed in a forest", graph: sampleForest, wantErr: true},
+		{name: "not a root", graph: sampleForest, tree: "b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			got, err := tt.graph(t).TreeRoot(tt.tree)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.ID).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_Forest(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleForest(t)
+
+	g.Expect(ids(gr.Trees())).To(Equal([]string{"a", "x"}))
+
+	// A new root is at the top, whatever it says
+	newRoot := NewEmptyNode()
+	newRoot.ID, newRoot.Height, newRoot.Position = "z", 5, 3
+	g.Expect(gr.EmplaceNode(&newRoot)).To(Succeed())
+	g.Expect(ids(gr.AtDepth(0))).To(Equal([]string{"a", "x", "z"}))
+	g.Expect(newRoot.Position).To(BeZero())
+	_, err := gr.RemoveNode("z", DeleteRefuse)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(gr.Validate()).To(BeEmpty())
+	root, err := gr.TreeOf("y")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(root.ID).To(Equal("x"))
+	root, err = gr.TreeOf("g")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(root.ID).To(Equal("a"))
+
+	// Queries stay within a tree
+	_, err = gr.LowestCommonAncestor("y", "g")
+	g.Expect(err).To(HaveOccurred())
+	below, err := gr.LevelBelow("x", 1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(below)).To(Equal([]string{"y"}))
+	g.Expect(ids(gr.AtDepth(0))).To(Equal([]string{"a", "x"}))
+	_, err = gr.Metrics("", 1)
+	g.Expect(err).To(HaveOccurred())
+
+	var buf bytes.Buffer
+	g.Expect(gr.WriteMermaid(&buf, "")).To(Succeed())
+	g.Expect(buf.String()).To(ContainSubstring("n7[\"x\"]\n  n8[\"y\"]\n"))
+	g.Expect(buf.String()).To(ContainSubstring("n7 --> n8\n"))
+	g.Expect(buf.String()).NotTo(ContainSubstring("--> n7\n"))
+
+	// Trees are named after their root, they follow renames, swaps and
+	// removals of it
+	g.Expect(gr.RenameNode("x", "contractors")).To(Succeed())
+	g.Expect(ids(gr.Clone().Trees())).To(Equal([]string{"a", "contractors"}))
+	_, err = gr.TreeRoot("x")
+	g.Expect(err).To(HaveOccurred())
+	_, err = gr.SwapNodes("contractors", "y")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(gr.Trees())).To(Equal([]string{"a", "y"}))
+	root, err = gr.TreeRoot("y")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(sortedChildren(root))).To(Equal([]string{"contractors"}))
+	_, err = gr.TreeRoot("contractors")
+	g.Expect(err).To(HaveOccurred())
+	_, err = gr.SwapNodes("y", "contractors")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(gr.MoveSubtree("y", "a")).To(Succeed())
+	_, err = gr.RemoveNode("contractors", DeleteRefuse)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(gr.Trees())).To(Equal([]string{"a"}))
+	g.Expect(gr.Metrics("", 1)).NotTo(BeNil())
+}
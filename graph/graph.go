@@ -14,33 +14,115 @@ var ErrDuplicateID = errors.New("id alreary exists")
 // ErrInvalidParentID ...
 var ErrInvalidParentID = errors.New("parent not found")
 
+// ErrMoveIntoSubtree triggers when a node is moved under one of it's own descendants
+var ErrMoveIntoSubtree = errors.New("can not move a node under it's own subtree")
+
 // Node is the building block of Graph. Node ID is chosen as
 // the unique identifier for each node. Which is not the best
 // practice, but will serve the given problem sufficiently.
 type Node struct {
-	ID       string `json:"id"`
-	ParID    string `json:"pid"`
-	Height   int    `json:"height"`
-	Children map[string]*Node
+	ID     string `json:"id"`
+	ParID  string `json:"pid"`
+	Height int    `json:"height"`
+	// Rank among the siblings, see OrderChildren. A node that gets a new parent
+	// goes after it's new siblings.
+	Position int `json:"position"`
+	// Number of children and of all descendants. Kept up to date by every
+	// change of the graph, so they are never counted per request.
+	DirectReports int `json:"direct_reports"`
+	TotalReports  int `json:"total_reports"`
+	Attributes
+	// Not serialized, a node would drag it's whole subtree along. See Tree.
+	Children map[string]*Node `json:"-"`
+}
+
+// Attributes is the org data carried by a node. It has no effect on the hierarchy.
+type Attributes struct {
+	Name   string            `json:"name,omitempty"`
+	Title  string            `json:"title,omitempty"`
+	Email  string            `json:"email,omitempty" validate:"omitempty,email"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// AttributesPatch is a partial update of Attributes. Nil fields are left
+// unchanged. Labels are merged, a label set to nil is removed.
+type AttributesPatch struct {
+	Name   *string            `json:"name"`
+	Title  *string            `json:"title"`
+	Email  *string            `json:"email"`
+	Labels map[string]*string `json:"labels"`
+}
+
+// Apply returns a copy of 'attrs' with the patch applied.
+func (p AttributesPatch) Apply(attrs Attributes) Attributes {
+	if p.Name != nil {
+		attrs.Name = *p.Name
+	}
+	if p.Title != nil {
+		attrs.Title = *p.Title
+	}
+	if p.Email != nil {
+		attrs.Email = *p.Email
+	}
+	labels := make(map[string]string, len(attrs.Labels))
+	for k, v := range attrs.Labels {
+		labels[k] = v
+	}
+	for k, v := range p.Labels {
+		if v == nil {
+			delete(labels, k)
+			continue
+		}
+		labels[k] = *v
+	}
+	attrs.Labels = labels
+	if len(labels) == 0 {
+		attrs.Labels = nil
+	}
+	return attrs
 }
 
-// Graph is in memory representation of hierarchy.
+// Graph is in memory representation of hierarchy. It is a forest, every node
+// without a parent is the root of a tree of it's own, see Trees.
 type Graph struct {
-	Root  *Node
+	Roots map[string]*Node
 	Nodes map[string]*Node
+	Edges []*Edge
+	// levels indexes the nodes by height, see AtDepth
+	levels map[int]map[string]*Node
+	// tour holds the span of every node, see IsAncestor
+	tour map[string]span
 }
 
-// Initialize creates a new graph object
+// Initialize creates a new graph object. Nodes are linked to their parent's
+// children, if they are not already.
 func Initialize(nodes []*Node) (*Graph, error) {
 	nodeMap := make(map[string]*Node)
-	g := Graph{}
+	g := Graph{Roots: make(map[string]*Node)}
 	for _, node := range nodes {
 		nodeMap[node.ID] = node
 		if node.ParID == "" {
-			g.Root = node
+			g.Roots[node.ID] = node
+		}
+		if node.Children == nil {
+			node.Children = make(map[string]*Node)
+		}
+	}
+	for _, node := range nodes {
+		if parNode, ok := nodeMap[node.ParID]; ok {
+			parNode.Children[node.ID] = node
 		}
 	}
 	g.Nodes = nodeMap
+	for _, node := range nodes {
+		if _, ok := nodeMap[node.ParID]; !ok {
+			countReports(node)
+		}
+	}
+	for _, node := range nodeMap {
+		g.index(node)
+	}
+	g.retour()
 	return &g, nil
 }
 
@@ -49,23 +131,42 @@ func NewEmptyNode() Node {
 	return Node{Children: make(map[string]*Node), Height: 0}
 }
 
-// EmplaceNode emplaces the given node into the graph. Updates the parent child relationship.
-func (g *Graph) EmplaceNode(node *Node) error {
+// CheckEmplaceNode validates that the given node can be emplaced into the graph.
+func (g *Graph) CheckEmplaceNode(node *Node) error {
 	if _, ok := g.Nodes[node.ID]; ok {
 		return ErrDuplicateID
 	}
-
-	parNode, ok := g.Nodes[node.ParID]
-	if !ok && g.Root != nil {
+	if _, ok := g.Nodes[node.ParID]; !ok && node.ParID != "" {
 		return ErrInvalidParentID
 	}
-	if g.Root == nil {
-		g.Root = node
+	return nil
+}
+
+// EmplaceNode emplaces the given node into the graph. Updates the parent child relationship.
+func (g *Graph) EmplaceNode(node *Node) error {
+	if err := g.CheckEmplaceNode(node); err != nil {
+		return err
+	}
+
+	parNode := g.Nodes[node.ParID]
+	if parNode == nil {
+		// A new tree, whatever the node says it starts at the top
+		node.Height, node.Position = 0, 0
+		if g.Roots == nil {
+			g.Roots = make(map[string]*Node)
+		}
+		g.Roots[node.ID] = node
 	}
+	node.DirectReports, node.TotalReports = 0, 0
 	g.Nodes[node.ID] = node
+	g.index(node)
 	if parNode != nil {
+		node.Position = nextPosition(parNode)
 		parNode.Children[node.ID] = node
+		parNode.DirectReports++
+		g.addReports(parNode, 1)
 	}
+	g.enter(node)
 	return nil
 }
 
@@ -76,7 +177,7 @@ func (g *Graph) UpdateParent(id, newPar string) error {
 	if curNode, ok = g.Nodes[id]; !ok {
 		return fmt.Errorf("invalid id %s", id)
 	}
-	if curNode == g.Root {
+	if g.isRoot(curNode) {
 		return fmt.Errorf("can not update parent of the root")
 	}
 
@@ -85,10 +186,12 @@ func (g *Graph) UpdateParent(id, newPar string) error {
 	}
 	prevParNode := g.Nodes[curNode.ParID]
 
-	// 1. Move children of cur node one level up
-	for _, node := range curNode.Children {
-		node.Height--
+	// 1. Move children of cur node one level up, along with their subtrees.
+	// They go after their new siblings, in their order.
+	for _, node := range sortedChildren(curNode) {
+		g.setHeight(node, prevParNode.Height+1)
 		node.ParID = prevParNode.ID
+		node.Position = nextPosition(prevParNode)
 		prevParNode.Children[node.ID] = node
 	}
 
@@ -97,27 +200,110 @@ func (g *Graph) UpdateParent(id, newPar string) error {
 
 	// 3. Remove cur node from it's prev parent
 	delete(prevParNode.Children, id)
+	prevParNode.DirectReports += curNode.DirectReports - 1
+	g.addReports(prevParNode, -1)
+	curNode.DirectReports, curNode.TotalReports = 0, 0
 
-	// 4. Add cur node to it's new parent
+	// 4. Add cur node to it's new parent, after it's new siblings
+	curNode.Position = nextPosition(newParNode)
 	newParNode.Children[id] = curNode
 
 	// 5. Set cur nodes parent to right value. Update height
 	curNode.ParID = newPar
-	curNode.Height = newParNode.Height + 1
+	g.setNodeHeight(curNode, newParNode.Height+1)
+	newParNode.DirectReports++
+	g.addReports(newParNode, 1)
+	// The lifted children stay within the span of their new parent, only the
+	// node itself, now a leaf, needs a new one
+	delete(g.tour, id)
+	g.enter(curNode)
+	return nil
+}
+
+// CheckMoveSubtree validates that node 'id' can be moved together with it's
+// subtree under 'newPar'.
+func (g *Graph) CheckMoveSubtree(id, newPar string) error {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return fmt.Errorf("invalid id %s", id)
+	}
+	if g.isRoot(curNode) {
+		return fmt.Errorf("can not update parent of the root")
+	}
+	newParNode, ok := g.Nodes[newPar]
+	if !ok {
+		return fmt.Errorf("invalid parent id")
+	}
+	if newParNode == curNode || g.isAncestor(curNode, newParNode) {
+		return ErrMoveIntoSubtree
+	}
+	return nil
+}
+
+// MoveSubtree sets the parent of node 'id' to 'newPar'. Unlike UpdateParent
+// the children stay with the node, and heights of the whole subtree are
+// recomputed.
+func (g *Graph) MoveSubtree(id, newPar string) error {
+	if err := g.CheckMoveSubtree(id, newPar); err != nil {
+		return err
+	}
+	curNode, newParNode := g.Nodes[id], g.Nodes[newPar]
+	size := curNode.TotalReports + 1
+	if prevParNode, ok := g.Nodes[curNode.ParID]; ok {
+		delete(prevParNode.Children, id)
+		prevParNode.DirectReports--
+		g.addReports(prevParNode, -size)
+	}
+	curNode.Position = nextPosition(newParNode)
+	newParNode.Children[id] = curNode
+	curNode.ParID = newPar
+	g.setHeight(curNode, newParNode.Height+1)
+	newParNode.DirectReports++
+	g.addReports(newParNode, size)
+	g.retour()
 	return nil
 }
 
-// GetChildren returns all the childrens of a given node
+// setHeight sets height of the node and fixes up heights of all of it's descendants.
+func (g *Graph) setHeight(node *Node, height int) {
+	g.setNodeHeight(node, height)
+	for _, child := range node.Children {
+		g.setHeight(child, height+1)
+	}
+}
+
+// addReports adds 'n' to the total reports of 'node' and all of it's ancestors.
+func (g *Graph) addReports(node *Node, n int) {
+	for ; node != nil; node = g.Nodes[node.ParID] {
+		node.TotalReports += n
+	}
+}
+
+// countReports counts the reports of the node and all of it's descendants
+// from scratch. Returns the total reports of the node.
+func countReports(node *Node) int {
+	node.DirectReports, node.TotalReports = len(node.Children), 0
+	for _, child := range node.Children {
+		node.TotalReports += countReports(child) + 1
+	}
+	return node.TotalReports
+}
+
+// UpdateAttributes replaces the attributes of node 'id'.
+func (g *Graph) UpdateAttributes(id string, attrs Attributes) error {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return fmt.Errorf("invalid id %s", id)
+	}
+	curNode.Attributes = attrs
+	return nil
+}
+
+// GetChildren returns all the childrens of a given node in their order
 func (g *Graph) GetChildren(id string) ([]*Node, error) {
 	curNode, ok := g.Nodes[id]
 	if !ok {
 		return nil, fmt.Errorf("invalid id %s", id)
 	}
-	children := make([]*Node, len(curNode.Children))
-	i := 0
-	for _, v := range curNode.Children {
-		children[i] = v
-		i++
-	}
-	return children, nil
+	return sortedChildren(curNode), nil
 }
@@ -0,0 +1,407 @@
+package graph
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// newTestGraph builds a graph from (id, parent id) pairs given in parent
+// first order. The first pair must be the root.
+func newTestGraph(t *testing.T, pairs ...[2]string) *Graph {
+	g, _ := Initialize(nil)
+	for _, p := range pairs {
+		node := NewEmptyNode()
+		node.ID, node.ParID = p[0], p[1]
+		if par, ok := g.Nodes[node.ParID]; ok {
+			node.Height = par.Height + 1
+		}
+		if err := g.EmplaceNode(&node); err != nil {
+			t.Fatalf("emplace %s: %v", node.ID, err)
+		}
+	}
+	return g
+}
+
+// sampleGraph is
+//
+//	    a
+//	   / \
+//	  b   c
+//	 / \   \
+//	d   e   f
+//	|
+//	g
+func sampleGraph(t *testing.T) *Graph {
+	return newTestGraph(t,
+		[2]string{"a", ""},
+		[2]string{"b", "a"},
+		[2]string{"c", "a"},
+		[2]string{"d", "b"},
+		[2]string{"e", "b"},
+		[2]string{"f", "c"},
+		[2]string{"g", "d"},
+	)
+}
+
+func ids(nodes []*Node) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = n.ID
+	}
+	return out
+}
+
+func TestGraph_Descendants(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		maxDepth int
+		want     []string
+		wantErr  bool
+	}{
+		{name: "FullTree", id: "a", maxDepth: NoDepthLimit, want: []string{"b", "c", "d", "e", "f", "g"}},
+		{name: "OneLevel", id: "a", maxDepth: 1, want: []string{"b", "c"}},
+		{name: "TwoLevels", id: "b", maxDepth: 2, want: []string{"d", "e", "g"}},
+		{name: "ZeroDepth", id: "b", maxDepth: 0, want: []string{}},
+		{name: "Leaf", id: "g", maxDepth: NoDepthLimit, want: []string{}},
+		{name: "InvalidID", id: "x", maxDepth: NoDepthLimit, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			got, err := sampleGraph(t).Descendants(tt.id, tt.maxDepth)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(got)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_Subtree(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	got, err := sampleGraph(t).Subtree("b", 1)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(&Tree{
+		ID: "b", ParID: "a", Height: 1, DirectReports: 2, TotalReports: 3,
+		Children: []*Tree{
+			{ID: "d", ParID: "b", Height: 2, DirectReports: 1, TotalReports: 1},
+			{ID: "e", ParID: "b", Height: 2, Position: 1},
+		},
+	}))
+}
+
+func TestNode_Shallow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	got := sampleGraph(t).Nodes["b"].Shallow()
+	g.Expect(got).To(Equal(&Tree{
+		ID: "b", ParID: "a", Height: 1, DirectReports: 2, TotalReports: 3,
+		ChildIDs: []string{"d", "e"},
+	}))
+
+	// A node never serializes it's subtree
+	b, err := json.Marshal(sampleGraph(t).Nodes["a"])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).NotTo(ContainSubstring(`"id":"b"`))
+}
+
+func TestGraph_Ancestors(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		want    []string
+		wantErr bool
+	}{
+		{name: "DeepNode", id: "g", want: []string{"d", "b", "a"}},
+		{name: "Child", id: "f", want: []string{"c", "a"}},
+		{name: "Root", id: "a", want: []string{}},
+		{name: "InvalidID", id: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			got, err := sampleGraph(t).Ancestors(tt.id)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(got)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_LowestCommonAncestor(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Cousins", a: "g", b: "f", want: "a"},
+		{name: "Siblings", a: "d", b: "e", want: "b"},
+		{name: "Ancestor", a: "b", b: "g", want: "b"},
+		{name: "Same", a: "e", b: "e", want: "e"},
+		{name: "InvalidID", a: "e", b: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			got, err := sampleGraph(t).LowestCommonAncestor(tt.a, tt.b)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.ID).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_Path(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{name: "Cousins", a: "g", b: "f", want: []string{"g", "d", "b", "a", "c", "f"}},
+		{name: "Down", a: "b", b: "g", want: []string{"b", "d", "g"}},
+		{name: "Up", a: "e", b: "a", want: []string{"e", "b", "a"}},
+		{name: "Same", a: "c", b: "c", want: []string{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			got, err := sampleGraph(t).Path(tt.a, tt.b)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(got)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_MoveSubtree(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gp := sampleGraph(t)
+	g.Expect(gp.MoveSubtree("b", "f")).To(Succeed())
+
+	g.Expect(gp.Nodes["a"].Children).NotTo(HaveKey("b"))
+	g.Expect(gp.Nodes["f"].Children).To(HaveKey("b"))
+	g.Expect(gp.Nodes["b"].ParID).To(Equal("f"))
+	g.Expect(gp.Nodes["b"].Children).To(HaveLen(2))
+	for id, height := range map[string]int{"b": 3, "d": 4, "e": 4, "g": 5} {
+		g.Expect(gp.Nodes[id].Height).To(Equal(height), id)
+	}
+}
+
+func TestGraph_CheckMoveSubtree(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		newPar string
+		want   error
+	}{
+		{name: "Valid", id: "d", newPar: "c"},
+		{name: "UnderSelf", id: "b", newPar: "b", want: ErrMoveIntoSubtree},
+		{name: "UnderDescendant", id: "b", newPar: "g", want: ErrMoveIntoSubtree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			err := sampleGraph(t).CheckMoveSubtree(tt.id, tt.newPar)
+			if tt.want == nil {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_RemoveNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		strategy    DeleteStrategy
+		wantRemoved []string
+		wantErr     bool
+	}{
+		{name: "RefuseLeaf", id: "e", strategy: DeleteRefuse, wantRemoved: []string{"e"}},
+		{name: "RefuseWithChildren", id: "b", strategy: DeleteRefuse, wantErr: true},
+		{name: "Reparent", id: "b", strategy: DeleteReparent, wantRemoved: []string{"b"}},
+		{name: "ReparentRoot", id: "a", strategy: DeleteReparent, wantErr: true},
+		{name: "Cascade", id: "b", strategy: DeleteCascade, wantRemoved: []string{"b", "d", "e", "g"}},
+		{name: "InvalidStrategy", id: "e", strategy: "drop", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			gp := sampleGraph(t)
+			got, err := gp.RemoveNode(tt.id, tt.strategy)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(gp.Nodes).To(HaveLen(7))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(got)).To(Equal(tt.wantRemoved))
+			g.Expect(gp.Nodes).To(HaveLen(7 - len(tt.wantRemoved)))
+			for _, id := range tt.wantRemoved {
+				g.Expect(gp.Nodes).NotTo(HaveKey(id))
+			}
+		})
+	}
+}
+
+func TestGraph_RemoveNode_Reparent(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gp := sampleGraph(t)
+	_, err := gp.RemoveNode("b", DeleteReparent)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(gp.Nodes["a"].Children).To(HaveLen(3))
+	g.Expect(gp.Nodes["d"].ParID).To(Equal("a"))
+	g.Expect(gp.Nodes["d"].Height).To(Equal(1))
+	g.Expect(gp.Nodes["g"].Height).To(Equal(2))
+}
+
+func TestShared_Update(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := NewShared(sampleGraph(t))
+	before := s.Snapshot()
+
+	g.Expect(s.Update(func(gp *Graph) error {
+		return gp.UpdateParent("d", "c")
+	})).To(Succeed())
+	g.Expect(s.Snapshot().Nodes["d"].ParID).To(Equal("c"))
+	g.Expect(before.Nodes["d"].ParID).To(Equal("b"), "old snapshot must not change")
+	g.Expect(before.Nodes["b"].Children).To(HaveKey("d"))
+
+	g.Expect(s.Update(func(gp *Graph) error {
+		if err := gp.UpdateParent("e", "c"); err != nil {
+			return err
+		}
+		return ErrInvalidParentID
+	})).To(Equal(ErrInvalidParentID))
+	g.Expect(s.Snapshot().Nodes["e"].ParID).To(Equal("b"), "failed update must not be published")
+}
+
+func TestShared_Concurrent(t *testing.T) {
+	s := NewShared(sampleGraph(t))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			par := []string{"b", "c"}[i%2]
+			if err := s.Update(func(gp *Graph) error { return gp.MoveSubtree("d", par) }); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		gp := s.Snapshot()
+		if _, err := gp.Descendants("a", NoDepthLimit); err != nil {
+			t.Fatal(err)
+		}
+		if d := gp.Nodes["d"]; gp.Nodes[d.ParID].Children[d.ID] != d {
+			t.Fatal("snapshot has a half applied move")
+		}
+	}
+	<-done
+}
+
+func TestAttributesPatch_Apply(t *testing.T) {
+	name, site := "Bob", "cph"
+	tests := []struct {
+		name  string
+		attrs Attributes
+		patch AttributesPatch
+		want  Attributes
+	}{
+		{
+			name:  "Empty",
+			attrs: Attributes{Name: "Alice", Title: "CEO"},
+			want:  Attributes{Name: "Alice", Title: "CEO"},
+		},
+		{
+			name:  "SetFields",
+			attrs: Attributes{Name: "Alice", Title: "CEO"},
+			patch: AttributesPatch{Name: &name, Labels: map[string]*string{"site": &site}},
+			want:  Attributes{Name: "Bob", Title: "CEO", Labels: map[string]string{"site": "cph"}},
+		},
+		{
+			name:  "RemoveLabel",
+			attrs: Attributes{Labels: map[string]string{"site": "cph", "team": "core"}},
+			patch: AttributesPatch{Labels: map[string]*string{"site": nil}},
+			want:  Attributes{Labels: map[string]string{"team": "core"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			g.Expect(tt.patch.Apply(tt.attrs)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_Reports(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	remove := func(id string, strategy DeleteStrategy) func(gr *Graph) error {
+		return func(gr *Graph) error {
+			_, err := gr.RemoveNode(id, strategy)
+			return err
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(gr *Graph) error
+	}{
+		{name: "initialize", change: func(gr *Graph) error { return nil }},
+		{name: "emplace", change: func(gr *Graph) error {
+			h := NewEmptyNode()
+			h.ID, h.ParID, h.Height = "h", "g", 4
+			return gr.EmplaceNode(&h)
+		}},
+		{name: "update parent", change: func(gr *Graph) error { return gr.UpdateParent("b", "f") }},
+		{name: "update parent under own child", change: func(gr *Graph) error { return gr.UpdateParent("b", "d") }},
+		{name: "move subtree", change: func(gr *Graph) error { return gr.MoveSubtree("b", "f") }},
+		{name: "remove leaf", change: remove("g", DeleteRefuse)},
+		{name: "remove reparent", change: remove("b", DeleteReparent)},
+		{name: "remove cascade", change: remove("b", DeleteCascade)},
+		{name: "rename", change: func(gr *Graph) error { return gr.RenameNode("d", "dan") }},
+		{name: "swap", change: func(gr *Graph) error {
+			_, err := gr.SwapNodes("b", "d")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gr := sampleGraph(t)
+			g.Expect(tt.change(gr)).To(Succeed())
+			for id, node := range gr.Nodes {
+				descendants, err := gr.Descendants(id, NoDepthLimit)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(node.DirectReports).To(Equal(len(node.Children)), id)
+				g.Expect(node.TotalReports).To(Equal(len(descendants)), id)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package graph
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Columns of an import CSV. Any other column is stored as a label.
+const (
+	columnID    = "id"
+	columnParID = "pid"
+	columnName  = "name"
+	columnTitle = "title"
+	columnEmail = "email"
+)
+
+// RowError is a problem with a single row of an import. Rows are counted from 1.
+type RowError struct {
+	Row   int    `json:"row"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// ParseCSV reads nodes from CSV. The first record is the header and must have
+// 'id' and 'pid' columns. 'name', 'title' and 'email' fill the attributes of
+// the same name, 'height' is ignored and every other non empty column becomes
+// a label. A 'label:' prefix is dropped from the label key, so labels named
+// like a column can be told apart.
+func ParseCSV(r io.Reader) ([]*Node, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing csv header")
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	for _, required := range []string{columnID, columnParID} {
+		if indexOf(header, required) < 0 {
+			return nil, fmt.Errorf("missing csv column %s", required)
+		}
+	}
+
+	nodes := make([]*Node, 0, len(records)-1)
+	for _, record := range records[1:] {
+		node := NewEmptyNode()
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch header[i] {
+			case columnID:
+				node.ID = value
+			case columnParID:
+				node.ParID = value
+			case columnName:
+				node.Name = value
+			case columnTitle:
+				node.Title = value
+			case columnEmail:
+				node.Email = value
+			case columnHeight:
+				// Heights follow from the parents
+			default:
+				if value == "" {
+					continue
+				}
+				if node.Labels == nil {
+					node.Labels = make(map[string]string)
+				}
+				node.Labels[strings.TrimPrefix(header[i], labelPrefix)] = value
+			}
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes, nil
+}
+
+// ParseNestedJSON reads nodes from a nested JSON tree, or an array of them, in
+// the shape of Tree. The parent of a nested node is the node it is nested in,
+// top level nodes keep the 'pid' they are given. Nodes are returned in pre-order.
+func ParseNestedJSON(r io.Reader) ([]*Node, error) {
+	var trees []*Tree
+	dec := json.NewDecoder(r)
+	raw := json.RawMessage{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		if err := json.Unmarshal(raw, &trees); err != nil {
+			return nil, err
+		}
+	} else {
+		tree := &Tree{}
+		if err := json.Unmarshal(raw, tree); err != nil {
+			return nil, err
+		}
+		trees = []*Tree{tree}
+	}
+
+	nodes := make([]*Node, 0)
+	var walk func(t *Tree, parID string)
+	walk = func(t *Tree, parID string) {
+		node := NewEmptyNode()
+		node.ID, node.ParID, node.Attributes = t.ID, parID, t.Attributes
+		nodes = append(nodes, &node)
+		for _, child := range t.Children {
+			walk(child, t.ID)
+		}
+	}
+	for _, t := range trees {
+		walk(t, t.ParID)
+	}
+	return nodes, nil
+}
+
+// PlanImport validates a batch of new nodes against the graph. Each node must
+// have a new unique id and a parent that is either in the graph or in the batch.
+// A node without a parent starts a new tree. On success the nodes are
+// returned as copies ordered parents first, with heights set, ready to be
+// emplaced one by one. Otherwise every invalid row is reported.
+func (g *Graph) PlanImport(rows []*Node) ([]*Node, []RowError) {
+	rowErrors := make([]RowError, 0)
+	fail := func(i int, format string, args ...interface{}) {
+		rowErrors = append(rowErrors, RowError{Row: i + 1, ID: rows[i].ID, Error: fmt.Sprintf(format, args...)})
+	}
+
+	// 1. Ids must be new and unique
+	batch := make(map[string]int, len(rows))
+	invalid := make(map[int]bool)
+	for i, row := range rows {
+		switch _, dup := batch[row.ID]; {
+		case row.ID == "":
+			fail(i, "missing id")
+		case dup:
+			fail(i, "%s, first seen at row %d", ErrDuplicateID, batch[row.ID]+1)
+		case g.Nodes[row.ID] != nil:
+			fail(i, "%s", ErrDuplicateID)
+		default:
+			batch[row.ID] = i
+			continue
+		}
+		invalid[i] = true
+	}
+
+	// 2. Parents must exist. Nodes hanging from the graph start the walk.
+	children := make(map[string][]int)
+	queue := make([]int, 0)
+	for i, row := range rows {
+		if invalid[i] {
+			continue
+		}
+		_, inBatch := batch[row.ParID]
+		switch {
+		case row.ParID == "", g.Nodes[row.ParID] != nil:
+			// A row without a parent starts a new tree
+			queue = append(queue, i)
+		case inBatch:
+			children[row.ParID] = append(children[row.ParID], i)
+		default:
+			fail(i, "%s: %s", ErrInvalidParentID, row.ParID)
+			invalid[i] = true
+		}
+	}
+
+	// 3. Walk parents first. What is not reached hangs from an invalid row or a cycle.
+	ordered := make([]*Node, 0, len(rows))
+	height := make(map[string]int, len(rows))
+	reached := make(map[int]bool, len(rows))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		node := *rows[i]
+		node.Children = make(map[string]*Node)
+		node.Height = 0
+		if par, ok := g.Nodes[node.ParID]; ok {
+			node.Height = par.Height + 1
+		} else if h, ok := height[node.ParID]; ok {
+			node.Height = h + 1
+		}
+		height[node.ID] = node.Height
+		reached[i] = true
+		ordered = append(ordered, &node)
+		queue = append(queue, children[node.ID]...)
+	}
+	for i, row := range rows {
+		if invalid[i] || reached[i] {
+			continue
+		}
+		if p, ok := batch[row.ParID]; ok && invalid[p] {
+			fail(i, "parent %s at row %d is invalid", row.ParID, p+1)
+			continue
+		}
+		fail(i, "%s is part of a cycle or below an invalid row", row.ID)
+	}
+
+	if len(rowErrors) > 0 {
+		sort.SliceStable(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+		return nil, rowErrors
+	}
+	return ordered, nil
+}
+
+func indexOf(values []string, v string) int {
+	for i := range values {
+		if values[i] == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,94 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_PlanImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       []*Node
+		want       map[string]int
+		wantErrors []int
+	}{
+		{
+			name: "ChildrenFirst",
+			rows: rawNodes([3]interface{}{"y", "x", 0}, [3]interface{}{"x", "g", 0}, [3]interface{}{"z", "a", 0}),
+			want: map[string]int{"x": 4, "y": 5, "z": 1},
+		},
+		{
+			name:       "DuplicateAndExisting",
+			rows:       rawNodes([3]interface{}{"x", "a", 0}, [3]interface{}{"x", "a", 0}, [3]interface{}{"b", "a", 0}),
+			wantErrors: []int{2, 3},
+		},
+		{
+			name:       "MissingParentAndBelow",
+			rows:       rawNodes([3]interface{}{"x", "nope", 0}, [3]interface{}{"y", "x", 0}, [3]interface{}{"z", "a", 0}),
+			wantErrors: []int{1, 2},
+		},
+		{
+			name:       "Cycle",
+			rows:       rawNodes([3]interface{}{"x", "y", 0}, [3]interface{}{"y", "x", 0}),
+			wantErrors: []int{1, 2},
+		},
+		{
+			name: "NewTree",
+			rows: rawNodes([3]interface{}{"y", "x", 0}, [3]interface{}{"x", "", 0}),
+			want: map[string]int{"x": 0, "y": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			gp := sampleGraph(t)
+			ordered, rowErrors := gp.PlanImport(tt.rows)
+			if tt.wantErrors != nil {
+				g.Expect(ordered).To(BeNil())
+				rows := make([]int, len(rowErrors))
+				for i, e := range rowErrors {
+					rows[i] = e.Row
+				}
+				g.Expect(rows).To(Equal(tt.wantErrors))
+				return
+			}
+			g.Expect(rowErrors).To(BeEmpty())
+			heights := make(map[string]int)
+			for _, n := range ordered {
+				g.Expect(gp.EmplaceNode(n)).To(Succeed())
+				heights[n.ID] = n.Height
+			}
+			g.Expect(heights).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nodes, err := ParseCSV(strings.NewReader("ID,pid,name,site\nroot,,Alice,cph\nb,root,Bob,\n"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nodes).To(HaveLen(2))
+	g.Expect(nodes[0].Attributes).To(Equal(Attributes{Name: "Alice", Labels: map[string]string{"site": "cph"}}))
+	g.Expect(nodes[1].ParID).To(Equal("root"))
+	g.Expect(nodes[1].Labels).To(BeNil())
+
+	_, err = ParseCSV(strings.NewReader("id,name\nroot,Alice\n"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestParseNestedJSON(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	nodes, err := ParseNestedJSON(strings.NewReader(`{"id":"x","pid":"a","children":[{"id":"y","children":[{"id":"z"}]},{"id":"w"}]}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(nodes)).To(Equal([]string{"x", "y", "z", "w"}))
+	g.Expect([]string{nodes[0].ParID, nodes[1].ParID, nodes[2].ParID, nodes[3].ParID}).To(Equal([]string{"a", "x", "y", "x"}))
+
+	nodes, err = ParseNestedJSON(strings.NewReader(`[{"id":"x"},{"id":"y"}]`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(nodes)).To(Equal([]string{"x", "y"}))
+}
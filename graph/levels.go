@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AtDepth returns every node at the given depth, i.e. with that height,
+// ordered by id. The root is at depth 0.
+func (g *Graph) AtDepth(depth int) []*Node {
+	nodes := make([]*Node, 0, len(g.levels[depth]))
+	for _, node := range g.levels[depth] {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// LevelBelow returns the nodes exactly 'k' levels below node 'id', ordered by
+// id. With k = 1 these are the children, with k = 2 the skip-level reports.
+// Only nodes at the right height are looked at, each is checked against the
+// tour index.
+func (g *Graph) LevelBelow(id string, k int) ([]*Node, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	if k < 0 {
+		return nil, fmt.Errorf("invalid number of levels %d", k)
+	}
+	nodes := make([]*Node, 0)
+	for _, node := range g.AtDepth(curNode.Height + k) {
+		if node == curNode || g.isAncestor(curNode, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// index files 'node' under it's height.
+func (g *Graph) index(node *Node) {
+	if g.levels == nil {
+		g.levels = make(map[int]map[string]*Node)
+	}
+	level, ok := g.levels[node.Height]
+	if !ok {
+		level = make(map[string]*Node)
+		g.levels[node.Height] = level
+	}
+	level[node.ID] = node
+}
+
+// unindex removes 'node' from the level of it's height.
+func (g *Graph) unindex(node *Node) {
+	delete(g.levels[node.Height], node.ID)
+	if len(g.levels[node.Height]) == 0 {
+		delete(g.levels, node.Height)
+	}
+}
+
+// setNodeHeight changes the height of a single node, keeping the index in step.
+func (g *Graph) setNodeHeight(node *Node, height int) {
+	g.unindex(node)
+	node.Height = height
+	g.index(node)
+}
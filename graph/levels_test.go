@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_AtDepth(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleGraph(t)
+
+	g.Expect(ids(gr.AtDepth(0))).To(Equal([]string{"a"}))
+	g.Expect(ids(gr.AtDepth(2))).To(Equal([]string{"d", "e", "f"}))
+	g.Expect(gr.AtDepth(4)).To(BeEmpty())
+
+	// The index follows every change of height
+	g.Expect(gr.MoveSubtree("d", "f")).To(Succeed())
+	g.Expect(ids(gr.AtDepth(3))).To(Equal([]string{"d"}))
+	g.Expect(ids(gr.AtDepth(4))).To(Equal([]string{"g"}))
+	g.Expect(gr.UpdateParent("b", "g")).To(Succeed())
+	g.Expect(ids(gr.AtDepth(1))).To(Equal([]string{"c", "e"}))
+	g.Expect(ids(gr.AtDepth(5))).To(Equal([]string{"b"}))
+	g.Expect(gr.RenameNode("b", "bob")).To(Succeed())
+	g.Expect(ids(gr.AtDepth(5))).To(Equal([]string{"bob"}))
+	_, err := gr.RemoveNode("d", DeleteCascade)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(gr.AtDepth(3)).To(BeEmpty())
+	g.Expect(gr.Clone().AtDepth(2)).To(HaveLen(1))
+}
+
+func TestGraph_AtDepth_Lift(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleGraph(t)
+
+	// d and e are lifted under a, g goes along with d
+	g.Expect(gr.UpdateParent("b", "c")).To(Succeed())
+	g.Expect(gr.Nodes["g"].Height).To(Equal(2))
+	g.Expect(ids(gr.AtDepth(2))).To(Equal([]string{"b", "f", "g"}))
+	g.Expect(gr.AtDepth(3)).To(BeEmpty())
+	below, err := gr.LevelBelow("a", 2)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(below)).To(Equal([]string{"b", "f", "g"}))
+	g.Expect(gr.Validate()).To(BeEmpty())
+}
+
+func TestGraph_LevelBelow(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		k       int
+		want    []string
+		wantErr bool
+	}{
+		{name: "itself", id: "b", k: 0, want: []string{"b"}},
+		{name: "children", id: "b", k: 1, want: []string{"d", "e"}},
+		{name: "skip level", id: "a", k: 2, want: []string{"d", "e", "f"}},
+		{name: "skip level of a subtree", id: "b", k: 2, want: []string{"g"}},
+		{name: "below the leaves", id: "c", k: 2, want: []string{}},
+		{name: "negative", id: "a", k: -1, wantErr: true},
+		{name: "invalid id", id: "x", k: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			got, err := sampleGraph(t).LevelBelow(tt.id, tt.k)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(got)).To(Equal(tt.want))
+		})
+	}
+}
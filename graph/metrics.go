@@ -0,0 +1,64 @@
+package graph
+
+import "sort"
+
+// Metrics describe the shape of a tree.
+type Metrics struct {
+	Root     string `json:"root"`
+	Nodes    int    `json:"nodes"`
+	MaxDepth int    `json:"max_depth"` // levels below the root
+	Leaves   int    `json:"leaves"`
+	// FanOut maps a number of direct reports to the number of nodes that have
+	// that many. Leaves are counted under 0.
+	FanOut       map[int]int `json:"fan_out"`
+	LargestSpans []Span      `json:"largest_spans"`
+}
+
+// Span is the number of direct reports of a node.
+type Span struct {
+	ID      string `json:"id"`
+	Reports int    `json:"reports"`
+}
+
+// Metrics computes the metrics of the subtree under 'id'. An empty id means
+// the only tree, name the tree by it's root when there are more. 'top' limits
+// the number of largest spans of control that are returned. Ties go to the
+// smallest id.
+func (g *Graph) Metrics(id string, top int) (*Metrics, error) {
+	root, err := g.exportRoot(id)
+	if err != nil {
+		return nil, err
+	}
+	descendants, err := g.Descendants(root.ID, NoDepthLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Metrics{Root: root.ID, FanOut: make(map[int]int)}
+	spans := make([]Span, 0)
+	for _, node := range append([]*Node{root}, descendants...) {
+		m.Nodes++
+		if depth := node.Height - root.Height; depth > m.MaxDepth {
+			m.MaxDepth = depth
+		}
+		n := len(node.Children)
+		m.FanOut[n]++
+		if n == 0 {
+			m.Leaves++
+			continue
+		}
+		spans = append(spans, Span{ID: node.ID, Reports: n})
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].Reports != spans[j].Reports {
+			return spans[i].Reports > spans[j].Reports
+		}
+		return spans[i].ID < spans[j].ID
+	})
+	if top >= 0 && len(spans) > top {
+		spans = spans[:top]
+	}
+	m.LargestSpans = spans
+	return m, nil
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_Metrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleGraph(t)
+
+	tests := []struct {
+		name string
+		id   string
+		top  int
+		want *Metrics
+		err  bool
+	}{
+		{name: "whole graph", top: 2, want: &Metrics{Root: "a", Nodes: 7, MaxDepth: 3, Leaves: 3,
+			FanOut:       map[int]int{0: 3, 1: 2, 2: 2},
+			LargestSpans: []Span{{ID: "a", Reports: 2}, {ID: "b", Reports: 2}}}},
+		{name: "subtree", id: "b", top: 5, want: &Metrics{Root: "b", Nodes: 4, MaxDepth: 2, Leaves: 2,
+			FanOut:       map[int]int{0: 2, 1: 1, 2: 1},
+			LargestSpans: []Span{{ID: "b", Reports: 2}, {ID: "d", Reports: 1}}}},
+		{name: "leaf", id: "g", top: 5, want: &Metrics{Root: "g", Nodes: 1, Leaves: 1,
+			FanOut: map[int]int{0: 1}, LargestSpans: []Span{}}},
+		{name: "invalid id", id: "x", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := gr.Metrics(tt.id, tt.top)
+			if tt.err {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(m).To(Equal(tt.want))
+		})
+	}
+}
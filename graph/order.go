@@ -0,0 +1,96 @@
+package graph
+
+import "fmt"
+
+// nextPosition returns the position after the last child of 'parNode'.
+func nextPosition(parNode *Node) int {
+	next := 0
+	for _, child := range parNode.Children {
+		if child.Position >= next {
+			next = child.Position + 1
+		}
+	}
+	return next
+}
+
+// CheckPlaceNode validates that node 'id' can be placed next to 'sibling'.
+func (g *Graph) CheckPlaceNode(id, sibling string) error {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return fmt.Errorf("invalid id %s", id)
+	}
+	sibNode, ok := g.Nodes[sibling]
+	if !ok {
+		return fmt.Errorf("invalid sibling id %s", sibling)
+	}
+	if id == sibling {
+		return fmt.Errorf("can not place %s next to itself", id)
+	}
+	if g.isRoot(curNode) || curNode.ParID != sibNode.ParID {
+		return fmt.Errorf("%s and %s are not siblings", id, sibling)
+	}
+	return nil
+}
+
+// PlaceNode moves node 'id' right before 'sibling' among their siblings, or
+// right after it if 'after' is set. Returns the siblings whose position changed.
+func (g *Graph) PlaceNode(id, sibling string, after bool) ([]*Node, error) {
+	if err := g.CheckPlaceNode(id, sibling); err != nil {
+		return nil, err
+	}
+	parNode := g.Nodes[g.Nodes[id].ParID]
+	order := make([]string, 0, len(parNode.Children))
+	for _, child := range sortedChildren(parNode) {
+		if child.ID == id {
+			continue
+		}
+		if child.ID == sibling && !after {
+			order = append(order, id)
+		}
+		order = append(order, child.ID)
+		if child.ID == sibling && after {
+			order = append(order, id)
+		}
+	}
+	return g.OrderChildren(parNode.ID, order)
+}
+
+// CheckOrderChildren validates that 'order' lists every child of node 'id' once.
+func (g *Graph) CheckOrderChildren(id string, order []string) error {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return fmt.Errorf("invalid id %s", id)
+	}
+	seen := make(map[string]bool, len(order))
+	for _, childID := range order {
+		if _, ok := curNode.Children[childID]; !ok {
+			return fmt.Errorf("%s is not a child of %s", childID, id)
+		}
+		if seen[childID] {
+			return fmt.Errorf("%s is listed more than once", childID)
+		}
+		seen[childID] = true
+	}
+	if len(seen) != len(curNode.Children) {
+		return fmt.Errorf("order must list all %d children of %s", len(curNode.Children), id)
+	}
+	return nil
+}
+
+// OrderChildren sets the order of the children of node 'id'. Positions are
+// renumbered from 0. Returns the children whose position changed.
+func (g *Graph) OrderChildren(id string, order []string) ([]*Node, error) {
+	if err := g.CheckOrderChildren(id, order); err != nil {
+		return nil, err
+	}
+	curNode := g.Nodes[id]
+	changed := make([]*Node, 0)
+	for i, childID := range order {
+		child := curNode.Children[childID]
+		if child.Position != i {
+			child.Position = i
+			changed = append(changed, child)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_PlaceNode(t *testing.T) {
+	gr := newTestGraph(t, [2]string{"a", ""}, [2]string{"d", "a"}, [2]string{"c", "a"}, [2]string{"b", "a"}, [2]string{"x", "d"})
+
+	tests := []struct {
+		name    string
+		id      string
+		sibling string
+		after   bool
+		want    []string
+		changed []string
+		wantErr bool
+	}{
+		{name: "before", id: "b", sibling: "d", want: []string{"b", "d", "c"}, changed: []string{"b", "d", "c"}},
+		{name: "after", id: "b", sibling: "c", after: true, want: []string{"d", "c", "b"}, changed: []string{"d", "c", "b"}},
+		{name: "already there", id: "b", sibling: "c", after: true, want: []string{"d", "c", "b"}, changed: []string{}},
+		{name: "itself", id: "b", sibling: "b", wantErr: true},
+		{name: "not a sibling", id: "b", sibling: "x", wantErr: true},
+		{name: "root", id: "a", sibling: "b", wantErr: true},
+	}
+
+	g := NewGomegaWithT(t)
+	children, _ := gr.GetChildren("a")
+	g.Expect(ids(children)).To(Equal([]string{"d", "c", "b"}), "insertion order")
+
+	// Cases build on each other
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			changed, err := gr.PlaceNode(tt.id, tt.sibling, tt.after)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(changed)).To(Equal(tt.changed))
+			children, _ := gr.GetChildren("a")
+			g.Expect(ids(children)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_OrderChildren(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleGraph(t)
+
+	changed, err := gr.OrderChildren("b", []string{"e", "d"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(changed)).To(Equal([]string{"e", "d"}))
+	descendants, _ := gr.Descendants("a", NoDepthLimit)
+	g.Expect(ids(descendants)).To(Equal([]string{"b", "c", "e", "d", "f", "g"}))
+
+	_, err = gr.OrderChildren("b", []string{"e"})
+	g.Expect(err).To(HaveOccurred(), "missing child")
+	_, err = gr.OrderChildren("b", []string{"e", "d", "d"})
+	g.Expect(err).To(HaveOccurred(), "duplicate child")
+	_, err = gr.OrderChildren("b", []string{"e", "f"})
+	g.Expect(err).To(HaveOccurred(), "not a child")
+
+	// New children go last
+	h := NewEmptyNode()
+	h.ID, h.ParID, h.Height = "h", "b", 2
+	g.Expect(gr.EmplaceNode(&h)).To(Succeed())
+	children, _ := gr.GetChildren("b")
+	g.Expect(ids(children)).To(Equal([]string{"e", "d", "h"}))
+}
+
+func TestGraph_Reparent_Order(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(gr *Graph) error
+		want   map[string][]string
+	}{
+		{
+			name:   "lift",
+			change: func(gr *Graph) error { return gr.UpdateParent("b", "c") },
+			want:   map[string][]string{"a": {"c", "d", "e"}, "c": {"f", "b"}},
+		},
+		{
+			name:   "lift under a lifted child",
+			change: func(gr *Graph) error { return gr.UpdateParent("b", "d") },
+			want:   map[string][]string{"a": {"c", "d", "e"}, "d": {"g", "b"}},
+		},
+		{
+			name:   "subtree",
+			change: func(gr *Graph) error { return gr.MoveSubtree("d", "c") },
+			want:   map[string][]string{"b": {"e"}, "c": {"f", "d"}},
+		},
+		{
+			name: "reparent",
+			change: func(gr *Graph) error {
+				_, err := gr.RemoveNode("b", DeleteReparent)
+				return err
+			},
+			want: map[string][]string{"a": {"c", "d", "e"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			gr := sampleGraph(t)
+			g.Expect(tt.change(gr)).To(Succeed())
+			for id, want := range tt.want {
+				children, err := gr.GetChildren(id)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(ids(children)).To(Equal(want), id)
+			}
+			for _, node := range gr.Nodes {
+				positions := make(map[int]bool)
+				for _, child := range node.Children {
+					g.Expect(positions).NotTo(HaveKey(child.Position), node.ID)
+					positions[child.Position] = true
+				}
+			}
+		})
+	}
+}
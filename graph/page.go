@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sort keys of QueryChildren
+const (
+	SortPosition      = "position"
+	SortID            = "id"
+	SortHeight        = "height"
+	SortName          = "name"
+	SortTitle         = "title"
+	SortEmail         = "email"
+	SortDirectReports = "direct_reports"
+	SortTotalReports  = "total_reports"
+)
+
+// ChildrenQuery selects, orders and pages the children of a node.
+type ChildrenQuery struct {
+	Sort string // one of the Sort keys, SortPosition if empty
+	Desc bool
+	// Case insensitive substring filters on the attributes of the same name
+	Name, Title, Email string
+	// Label filter, either 'key' to require the label or 'key:value' to
+	// require it with that value
+	Label string
+	// Cursor continues after the last node of a previous page
+	Cursor string
+	// Limit is the size of a page, 0 means no limit
+	Limit int
+}
+
+// Page is one page of children. Next is the cursor of the following page,
+// empty on the last page.
+type Page struct {
+	Nodes []*Node
+	Next  string
+}
+
+// pageKey is the position of a node in the sort order. It is also what a
+// cursor holds, so that paging keeps working when nodes come and go.
+type pageKey struct {
+	Sort string `json:"sort"`
+	Desc bool   `json:"desc,omitempty"`
+	Num  int    `json:"n,omitempty"`
+	Str  string `json:"s,omitempty"`
+	ID   string `json:"id"`
+}
+
+// QueryChildren returns the children of node 'id' that match the filters of
+// the query, sorted and limited to one page. Ties in the sort order are broken
+// by id, so pages never overlap or skip nodes.
+func (g *Graph) QueryChildren(id string, q ChildrenQuery) (*Page, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	if q.Sort == "" {
+		q.Sort = SortPosition
+	}
+	if _, err := sortKey(&Node{}, q.Sort, q.Desc); err != nil {
+		return nil, err
+	}
+	var start *pageKey
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil || c.Sort != q.Sort || c.Desc != q.Desc {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		start = c
+	}
+
+	keys := make(map[*Node]pageKey)
+	nodes := make([]*Node, 0)
+	for _, child := range curNode.Children {
+		if !q.matches(child) {
+			continue
+		}
+		key, _ := sortKey(child, q.Sort, q.Desc)
+		if start != nil && !key.after(*start) {
+			continue
+		}
+		keys[child] = key
+		nodes = append(nodes, child)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return keys[nodes[j]].after(keys[nodes[i]]) })
+
+	page := &Page{Nodes: nodes}
+	if q.Limit > 0 && len(nodes) > q.Limit {
+		page.Nodes = nodes[:q.Limit]
+		page.Next = encodeCursor(keys[page.Nodes[q.Limit-1]])
+	}
+	return page, nil
+}
+
+func (q ChildrenQuery) matches(node *Node) bool {
+	contains := func(value, filter string) bool {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(filter))
+	}
+	if !contains(node.Name, q.Name) || !contains(node.Title, q.Title) || !contains(node.Email, q.Email) {
+		return false
+	}
+	if q.Label == "" {
+		return true
+	}
+	parts := strings.SplitN(q.Label, ":", 2)
+	value, ok := node.Labels[parts[0]]
+	return ok && (len(parts) == 1 || value == parts[1])
+}
+
+func sortKey(node *Node, by string, desc bool) (pageKey, error) {
+	key := pageKey{Sort: by, Desc: desc, ID: node.ID}
+	switch by {
+	case SortPosition:
+		key.Num = node.Position
+	case SortID:
+	case SortHeight:
+		key.Num = node.Height
+	case SortName:
+		key.Str = node.Name
+	case SortTitle:
+		key.Str = node.Title
+	case SortEmail:
+		key.Str = node.Email
+	case SortDirectReports:
+		key.Num = node.DirectReports
+	case SortTotalReports:
+		key.Num = node.TotalReports
+	default:
+		return key, fmt.Errorf("invalid sort key %s", by)
+	}
+	return key, nil
+}
+
+// after tells whether 'k' comes after 'other' in the sort order.
+func (k pageKey) after(other pageKey) bool {
+	var greater bool
+	switch {
+	case k.Num != other.Num:
+		greater = k.Num > other.Num
+	case k.Str != other.Str:
+		greater = k.Str > other.Str
+	case k.ID != other.ID:
+		greater = k.ID > other.ID
+	default:
+		return false
+	}
+	return greater != k.Desc
+}
+
+func encodeCursor(k pageKey) string {
+	b, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(cursor string) (*pageKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	k := &pageKey{}
+	if err := json.Unmarshal(b, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
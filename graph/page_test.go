@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_QueryChildren(t *testing.T) {
+	gr := newTestGraph(t, [2]string{"a", ""}, [2]string{"e", "a"}, [2]string{"c", "a"}, [2]string{"d", "a"}, [2]string{"b", "a"}, [2]string{"x", "b"})
+	attrs := map[string]Attributes{
+		"b": {Name: "Bob", Title: "Support Engineer", Labels: map[string]string{"site": "cph"}},
+		"c": {Name: "Carol", Title: "Support Lead", Labels: map[string]string{"site": "ber"}},
+		"d": {Name: "Dan", Title: "Support Engineer"},
+		"e": {Name: "Eve", Title: "Sales", Labels: map[string]string{"site": "cph"}},
+	}
+	for id, a := range attrs {
+		if err := gr.UpdateAttributes(id, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		q       ChildrenQuery
+		want    []string
+		wantErr bool
+	}{
+		{name: "position", want: []string{"e", "c", "d", "b"}},
+		{name: "id", q: ChildrenQuery{Sort: SortID}, want: []string{"b", "c", "d", "e"}},
+		{name: "name descending", q: ChildrenQuery{Sort: SortName, Desc: true}, want: []string{"e", "d", "c", "b"}},
+		{name: "reports, ties by id", q: ChildrenQuery{Sort: SortDirectReports, Desc: true}, want: []string{"b", "e", "d", "c"}},
+		{name: "title filter", q: ChildrenQuery{Sort: SortID, Title: "engineer"}, want: []string{"b", "d"}},
+		{name: "label", q: ChildrenQuery{Label: "site"}, want: []string{"e", "c", "b"}},
+		{name: "label value", q: ChildrenQuery{Label: "site:cph"}, want: []string{"e", "b"}},
+		{name: "invalid sort", q: ChildrenQuery{Sort: "salary"}, wantErr: true},
+		{name: "invalid cursor", q: ChildrenQuery{Cursor: "nope"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			page, err := gr.QueryChildren("a", tt.q)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(page.Nodes)).To(Equal(tt.want))
+			g.Expect(page.Next).To(BeEmpty())
+		})
+	}
+}
+
+func TestGraph_QueryChildrenPages(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := newTestGraph(t, [2]string{"a", ""}, [2]string{"e", "a"}, [2]string{"c", "a"}, [2]string{"d", "a"}, [2]string{"b", "a"})
+
+	q := ChildrenQuery{Sort: SortID, Limit: 2}
+	page, err := gr.QueryChildren("a", q)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(page.Nodes)).To(Equal([]string{"b", "c"}))
+	g.Expect(page.Next).NotTo(BeEmpty())
+
+	// Removing the last node of a page does not break the cursor
+	_, err = gr.RemoveNode("c", DeleteRefuse)
+	g.Expect(err).NotTo(HaveOccurred())
+	q.Cursor = page.Next
+	page, err = gr.QueryChildren("a", q)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ids(page.Nodes)).To(Equal([]string{"d", "e"}))
+	g.Expect(page.Next).To(BeEmpty())
+
+	q.Sort = SortName
+	_, err = gr.QueryChildren("a", q)
+	g.Expect(err).To(HaveOccurred(), "cursor of another sort order")
+}
@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrHasChildren triggers when a node with children is removed with DeleteRefuse
+var ErrHasChildren = errors.New("node has children")
+
+// DeleteStrategy tells what happens to the children of a removed node.
+type DeleteStrategy string
+
+// Supported delete strategies
+const (
+	// DeleteRefuse only removes nodes without children.
+	DeleteRefuse DeleteStrategy = "refuse"
+	// DeleteReparent attaches the children to the parent of the removed node.
+	DeleteReparent DeleteStrategy = "reparent"
+	// DeleteCascade removes the whole subtree.
+	DeleteCascade DeleteStrategy = "cascade"
+)
+
+// CheckRemoveNode validates that node 'id' can be removed with the given strategy.
+func (g *Graph) CheckRemoveNode(id string, strategy DeleteStrategy) error {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return fmt.Errorf("invalid id %s", id)
+	}
+	switch strategy {
+	case DeleteRefuse:
+		if len(curNode.Children) > 0 {
+			return ErrHasChildren
+		}
+	case DeleteReparent:
+		if g.isRoot(curNode) && len(curNode.Children) > 0 {
+			return fmt.Errorf("can not reparent children of the root")
+		}
+	case DeleteCascade:
+	default:
+		return fmt.Errorf("invalid delete strategy %s", strategy)
+	}
+	return nil
+}
+
+// RemoveNode removes node 'id' from the graph and returns every removed node.
+// What happens to the children is decided by the strategy.
+func (g *Graph) RemoveNode(id string, strategy DeleteStrategy) ([]*Node, error) {
+	if err := g.CheckRemoveNode(id, strategy); err != nil {
+		return nil, err
+	}
+	curNode := g.Nodes[id]
+	removed := []*Node{curNode}
+
+	switch strategy {
+	case DeleteReparent:
+		parNode := g.Nodes[curNode.ParID]
+		// The children go after their new siblings, in their order
+		for _, child := range sortedChildren(curNode) {
+			child.ParID = parNode.ID
+			child.Position = nextPosition(parNode)
+			parNode.Children[child.ID] = child
+			g.setHeight(child, parNode.Height+1)
+		}
+		if parNode != nil {
+			// The generic removal below takes the whole subtree off, put the children back
+			parNode.DirectReports += curNode.DirectReports
+			g.addReports(parNode, curNode.TotalReports)
+		}
+	case DeleteCascade:
+		descendants, err := g.Descendants(id, NoDepthLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range descendants {
+			g.unindex(node)
+			delete(g.tour, node.ID)
+			delete(g.Nodes, node.ID)
+		}
+		removed = append(removed, descendants...)
+	}
+
+	if parNode, ok := g.Nodes[curNode.ParID]; ok {
+		delete(parNode.Children, id)
+		parNode.DirectReports--
+		g.addReports(parNode, -(curNode.TotalReports + 1))
+	}
+	curNode.Children = make(map[string]*Node)
+	g.unindex(curNode)
+	// Reparented children are still within the span of the parent
+	delete(g.tour, id)
+	delete(g.Nodes, id)
+	delete(g.Roots, id)
+
+	removedIDs := make(map[string]bool, len(removed))
+	for _, node := range removed {
+		removedIDs[node.ID] = true
+	}
+	g.removeEdgesOf(removedIDs)
+	return removed, nil
+}
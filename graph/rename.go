@@ -0,0 +1,76 @@
+package graph
+
+import "fmt"
+
+// Rename records a node getting a new id.
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CheckRenameNode validates that node 'id' can be renamed to 'newID'.
+func (g *Graph) CheckRenameNode(id, newID string) error {
+	if _, ok := g.Nodes[id]; !ok {
+		return fmt.Errorf("invalid id %s", id)
+	}
+	if newID == "" {
+		return fmt.Errorf("new id can not be empty")
+	}
+	if _, ok := g.Nodes[newID]; ok {
+		return ErrDuplicateID
+	}
+	return nil
+}
+
+// RenameNode changes the id of node 'id' to 'newID'. The children of the node
+// and the secondary edges it is part of refer to the new id afterwards. A
+// renamed root renames it's tree, see Trees.
+func (g *Graph) RenameNode(id, newID string) error {
+	if err := g.CheckRenameNode(id, newID); err != nil {
+		return err
+	}
+	curNode := g.Nodes[id]
+	g.unindex(curNode)
+	delete(g.Nodes, id)
+	curNode.ID = newID
+	g.Nodes[newID] = curNode
+	g.index(curNode)
+	if g.Roots[id] == curNode {
+		delete(g.Roots, id)
+		g.Roots[newID] = curNode
+	}
+	if s, ok := g.tour[id]; ok {
+		delete(g.tour, id)
+		g.tour[newID] = s
+	}
+	if parNode, ok := g.Nodes[curNode.ParID]; ok {
+		delete(parNode.Children, id)
+		parNode.Children[newID] = curNode
+	}
+	for _, child := range curNode.Children {
+		child.ParID = newID
+	}
+	g.Edges = renameEdges(g.Edges, id, newID)
+	return nil
+}
+
+// renameEdges returns the edges with every reference to 'from' replaced by
+// 'to'. Edges are shared between versions of a graph, so changed edges are copies.
+func renameEdges(edges []*Edge, from, to string) []*Edge {
+	renamed := make([]*Edge, len(edges))
+	for i, e := range edges {
+		if e.From != from && e.To != from {
+			renamed[i] = e
+			continue
+		}
+		edge := *e
+		if edge.From == from {
+			edge.From = to
+		}
+		if edge.To == from {
+			edge.To = to
+		}
+		renamed[i] = &edge
+	}
+	return renamed
+}
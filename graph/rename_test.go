@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_RenameNode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	gr := sampleGraph(t)
+	edge := &Edge{From: "g", To: "b", Type: "dotted-line"}
+	g.Expect(gr.AddEdge(edge)).To(Succeed())
+	before := gr.Clone()
+
+	g.Expect(gr.RenameNode("b", "bob")).To(Succeed())
+	g.Expect(gr.Nodes).NotTo(HaveKey("b"))
+	g.Expect(gr.Nodes["bob"].ID).To(Equal("bob"))
+	g.Expect(gr.Nodes["a"].Children).To(HaveKey("bob"))
+	g.Expect(gr.Nodes["a"].Children).NotTo(HaveKey("b"))
+	g.Expect(gr.Nodes["d"].ParID).To(Equal("bob"))
+	g.Expect(gr.Nodes["e"].ParID).To(Equal("bob"))
+	g.Expect(gr.Edges).To(Equal([]*Edge{{From: "g", To: "bob", Type: "dotted-line"}}))
+	g.Expect(edge.To).To(Equal("b"), "shared edges are not modified")
+	g.Expect(gr.Validate()).To(BeEmpty())
+
+	g.Expect(gr.RenameNode("a", "root")).To(Succeed())
+	g.Expect(ids(gr.Trees())).To(Equal([]string{"root"}))
+	g.Expect(gr.Nodes["bob"].ParID).To(Equal("root"))
+
+	g.Expect(gr.RenameNode("c", "bob")).To(MatchError(ErrDuplicateID))
+	g.Expect(gr.RenameNode("x", "y")).To(HaveOccurred())
+	g.Expect(gr.RenameNode("c", "")).To(HaveOccurred())
+
+	// The change log follows renames, and they can be reverted
+	after := before.Clone()
+	g.Expect(after.RenameNode("b", "bob")).To(Succeed())
+	e := NewEvent(EventRename, "alice", before, after)
+	e.Rename = &Rename{From: "b", To: "bob"}
+
+	replayed, err := Replay([]*Event{NewEvent(EventBaseline, "system", &Graph{}, before), NewEdgeEvent(EventEdgeAdd, "system", edge), e})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(Compare(replayed, after).Empty()).To(BeTrue())
+	g.Expect(replayed.Edges).To(Equal(after.Edges))
+
+	reverted, err := after.Reverted(e)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(Compare(reverted, before).Empty()).To(BeTrue())
+	g.Expect(reverted.Edges).To(Equal(before.Edges))
+}
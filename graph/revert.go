@@ -0,0 +1,106 @@
+package graph
+
+import "fmt"
+
+// Reverted returns a copy of the graph with the change recorded by 'e' undone.
+// Only what the change did is undone: a created node is removed, a removed node
+// comes back and a node that got a new parent or new attributes gets the old
+// ones back, same for the position among the siblings. Children that were
+// lifted by a move go back to the moved node. Heights follow from the restored
+// parents. A revert is refused if a node it touches has been changed the same
+// way since, or if undoing the change would break the hierarchy, e.g. a
+// created node got children since. Edges removed along with a node do not come
+// back. The graph is not modified.
+func (g *Graph) Reverted(e *Event) (*Graph, error) {
+	next := g.Clone()
+	switch e.Kind {
+	case EventBaseline:
+		return nil, fmt.Errorf("the baseline can not be reverted")
+	case EventEdgeAdd:
+		if err := next.RemoveEdge(e.Edge.From, e.Edge.To, e.Edge.Type); err != nil {
+			return nil, err
+		}
+		return next, nil
+	case EventEdgeRemove:
+		if err := next.AddEdge(e.Edge); err != nil {
+			return nil, err
+		}
+		return next, nil
+	}
+
+	before := make(map[string]*Tree, len(e.Before))
+	for _, t := range e.Before {
+		before[t.ID] = t
+	}
+	after := make(map[string]*Tree, len(e.After))
+	for _, t := range e.After {
+		after[t.ID] = t
+		node, ok := next.Nodes[t.ID]
+		if !ok {
+			return nil, fmt.Errorf("%s has been removed since", t.ID)
+		}
+		old, existed := before[t.ID]
+		if !existed {
+			delete(next.Nodes, t.ID)
+			continue
+		}
+		if old.ParID != t.ParID {
+			if node.ParID != t.ParID {
+				return nil, fmt.Errorf("%s has been moved since", t.ID)
+			}
+			node.ParID = old.ParID
+		}
+		if old.Position != t.Position {
+			if node.Position != t.Position {
+				return nil, fmt.Errorf("%s has been reordered since", t.ID)
+			}
+			node.Position = old.Position
+		}
+		if !attributesEqual(old.Attributes, t.Attributes) {
+			if !attributesEqual(node.Attributes, t.Attributes) {
+				return nil, fmt.Errorf("attributes of %s have been changed since", t.ID)
+			}
+			node.Attributes = old.Attributes
+		}
+	}
+	for _, t := range e.Before {
+		if _, ok := after[t.ID]; ok {
+			continue
+		}
+		if _, ok := next.Nodes[t.ID]; ok {
+			return nil, fmt.Errorf("%s has been created again since", t.ID)
+		}
+		node := NewEmptyNode()
+		node.ID, node.ParID, node.Position, node.Attributes = t.ID, t.ParID, t.Position, t.Attributes
+		next.Nodes[t.ID] = &node
+	}
+	if e.Rename != nil {
+		next.Edges = renameEdges(next.Edges, e.Rename.To, e.Rename.From)
+	}
+	return next.relinked()
+}
+
+// relinked rebuilds children, root and heights from the parent of each node.
+// It fails if the parents do not form a valid forest.
+func (g *Graph) relinked() (*Graph, error) {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		node.Children = nil
+		nodes = append(nodes, node)
+	}
+	for _, p := range Validate(nodes) {
+		if p.Kind != ProblemHeight {
+			return nil, fmt.Errorf("%s %s: %s", p.Kind, p.ID, p.Detail)
+		}
+	}
+	relinked, err := Initialize(nodes)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range relinked.Roots {
+		relinked.setHeight(root, 0)
+	}
+	relinked.Edges = g.Edges
+	relinked.removeEdgesOf(missing(relinked, g.Edges))
+	return relinked, nil
+}
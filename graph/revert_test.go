@@ -0,0 +1,101 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_Reverted(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// change records fn applied on a copy of 'live' and returns the copy
+	change := func(live *Graph, kind EventKind, fn func(next *Graph) error) (*Graph, *Event) {
+		next := live.Clone()
+		g.Expect(fn(next)).To(Succeed())
+		return next, NewEvent(kind, "alice", live, next)
+	}
+	create := func(id, parID string) func(next *Graph) error {
+		return func(next *Graph) error {
+			node := NewEmptyNode()
+			node.ID, node.ParID, node.Height = id, parID, next.Nodes[parID].Height+1
+			return next.EmplaceNode(&node)
+		}
+	}
+	remove := func(id string, strategy DeleteStrategy) func(next *Graph) error {
+		return func(next *Graph) error {
+			_, err := next.RemoveNode(id, strategy)
+			return err
+		}
+	}
+
+	tests := []struct {
+		name  string
+		kind  EventKind
+		fn    func(next *Graph) error
+		since func(next *Graph) error // applied after the change, before the revert
+		err   bool
+	}{
+		{name: "create", kind: EventCreate, fn: create("h", "g")},
+		{name: "move with lifted children", kind: EventUpdateParent,
+			fn: func(next *Graph) error { return next.UpdateParent("b", "c") }},
+		{name: "subtree move", kind: EventMoveSubtree,
+			fn: func(next *Graph) error { return next.MoveSubtree("d", "f") }},
+		{name: "cascade delete", kind: EventDelete, fn: remove("b", DeleteCascade)},
+		{name: "reparent delete", kind: EventDelete, fn: remove("d", DeleteReparent)},
+		{name: "attributes", kind: EventAttributes,
+			fn: func(next *Graph) error { return next.UpdateAttributes("e", Attributes{Title: "CTO"}) }},
+		{name: "unrelated change since", kind: EventMoveSubtree,
+			fn:    func(next *Graph) error { return next.MoveSubtree("d", "f") },
+			since: func(next *Graph) error { return next.UpdateAttributes("d", Attributes{Name: "Dan"}) }},
+		{name: "moved since", kind: EventMoveSubtree,
+			fn:    func(next *Graph) error { return next.MoveSubtree("d", "f") },
+			since: func(next *Graph) error { return next.MoveSubtree("d", "e") }, err: true},
+		{name: "created node got children since", kind: EventCreate, fn: create("h", "g"),
+			since: create("i", "h"), err: true},
+		{name: "removed node created again since", kind: EventDelete, fn: remove("g", DeleteRefuse),
+			since: create("g", "a"), err: true},
+		{name: "parent of removed node removed since", kind: EventDelete, fn: remove("g", DeleteRefuse),
+			since: remove("d", DeleteRefuse), err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := sampleGraph(t)
+			live, e := change(original, tt.kind, tt.fn)
+			if tt.since != nil {
+				live, _ = change(live, EventAttributes, tt.since)
+			}
+
+			reverted, err := live.Reverted(e)
+			if tt.err {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(reverted.Validate()).To(BeEmpty())
+			if tt.since == nil {
+				g.Expect(Compare(original, reverted).Empty()).To(BeTrue())
+			}
+		})
+	}
+}
+
+func TestGraph_RevertedEdges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	live := sampleGraph(t)
+	edge := &Edge{From: "g", To: "c", Type: "dotted-line"}
+	g.Expect(live.AddEdge(edge)).To(Succeed())
+
+	reverted, err := live.Reverted(NewEdgeEvent(EventEdgeAdd, "alice", edge))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reverted.Edges).To(BeEmpty())
+	g.Expect(live.Edges).To(HaveLen(1), "the graph is not modified")
+
+	_, err = live.Reverted(NewEdgeEvent(EventEdgeRemove, "alice", edge))
+	g.Expect(err).To(MatchError(ErrDuplicateEdge))
+
+	_, err = live.Reverted(NewEvent(EventBaseline, "system", &Graph{}, live))
+	g.Expect(err).To(HaveOccurred())
+}
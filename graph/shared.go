@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Shared makes a Graph safe to use from concurrent goroutines. Readers get an
+// immutable snapshot of the latest version. Writers are serialized, apply their
+// changes to a private copy and publish it atomically once all of them succeed.
+// So a reader never sees a half applied change.
+//
+// The copy is a full one, so every write costs O(n) in the number of nodes,
+// however few it touches. The incremental upkeep of counts, levels and the
+// tour keeps the change itself cheap, not the write. That is accepted: a
+// hierarchy changes rarely and is read a lot, and lock free reads of a
+// consistent version are worth more than cheap writes.
+type Shared struct {
+	mu      sync.Mutex   // serializes writers
+	current atomic.Value // *Graph, never modified once stored
+}
+
+// NewShared publishes 'g' as the first version. 'g' must not be modified afterwards.
+func NewShared(g *Graph) *Shared {
+	s := &Shared{}
+	s.current.Store(g)
+	return s
+}
+
+// Snapshot returns the latest published version of the graph. The returned graph
+// and it's nodes are shared between readers and must not be modified.
+func (s *Shared) Snapshot() *Graph {
+	return s.current.Load().(*Graph)
+}
+
+// Update calls 'fn' with a private copy of the latest version. If 'fn' succeeds
+// the copy is published as the new version, otherwise it is thrown away.
+// Calls to Update are serialized, so 'fn' can safely write to the persistent
+// layer too and keep it in step with the graph.
+func (s *Shared) Update(fn func(g *Graph) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.Snapshot().Clone()
+	if err := fn(next); err != nil {
+		return err
+	}
+	s.current.Store(next)
+	return nil
+}
+
+// Clone returns a deep copy of the graph. Changes on the copy are not visible
+// on the original and vice versa.
+func (g *Graph) Clone() *Graph {
+	clone := &Graph{Nodes: make(map[string]*Node, len(g.Nodes))}
+	for id, node := range g.Nodes {
+		n := *node
+		n.Children = make(map[string]*Node, len(node.Children))
+		if node.Labels != nil {
+			n.Labels = make(map[string]string, len(node.Labels))
+			for k, v := range node.Labels {
+				n.Labels[k] = v
+			}
+		}
+		clone.Nodes[id] = &n
+		clone.index(&n)
+	}
+	for id, node := range g.Nodes {
+		for childID := range node.Children {
+			if child, ok := clone.Nodes[childID]; ok {
+				clone.Nodes[id].Children[childID] = child
+			}
+		}
+	}
+	clone.Roots = make(map[string]*Node, len(g.Roots))
+	for id := range g.Roots {
+		clone.Roots[id] = clone.Nodes[id]
+	}
+	clone.tour = make(map[string]span, len(g.tour))
+	for id, s := range g.tour {
+		clone.tour[id] = s
+	}
+	// Edges are never modified in place, sharing them is safe
+	clone.Edges = append([]*Edge(nil), g.Edges...)
+	return clone
+}
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CheckSwapNodes validates that nodes 'a' and 'b' can swap their positions.
+func (g *Graph) CheckSwapNodes(a, b string) error {
+	for _, id := range []string{a, b} {
+		if _, ok := g.Nodes[id]; !ok {
+			return fmt.Errorf("invalid id %s", id)
+		}
+	}
+	if a == b {
+		return fmt.Errorf("can not swap %s with itself", a)
+	}
+	return nil
+}
+
+// SwapNodes swaps the positions of nodes 'a' and 'b' in the tree. Each takes
+// the parent, the children and the height of the other. If one is the parent
+// of the other, the child becomes the parent. The shape of the tree does not
+// change, so no other height, position or report count changes. A swapped root
+// hands it's tree over, the tree is named after the new root then, see Trees.
+// Returns every node that got a new parent.
+func (g *Graph) SwapNodes(a, b string) ([]*Node, error) {
+	if err := g.CheckSwapNodes(a, b); err != nil {
+		return nil, err
+	}
+	nodeA, nodeB := g.Nodes[a], g.Nodes[b]
+	swap := func(id string) string {
+		switch id {
+		case a:
+			return b
+		case b:
+			return a
+		}
+		return id
+	}
+
+	affected := map[string]*Node{a: nodeA, b: nodeB}
+	for _, node := range []*Node{nodeA, nodeB} {
+		for id, child := range node.Children {
+			affected[id] = child
+		}
+	}
+	// A node takes the parent of the node at it's new position
+	newPar := make(map[string]string, len(affected))
+	for id := range affected {
+		newPar[id] = swap(g.Nodes[swap(id)].ParID)
+	}
+
+	for id, node := range affected {
+		if parNode, ok := g.Nodes[node.ParID]; ok {
+			delete(parNode.Children, id)
+		}
+	}
+	moved := make([]*Node, 0, len(affected))
+	for id, node := range affected {
+		node.ParID = newPar[id]
+		if parNode, ok := g.Nodes[node.ParID]; ok {
+			parNode.Children[id] = node
+		}
+		moved = append(moved, node)
+	}
+	// Heights and counts belong to the position
+	heightA, heightB := nodeA.Height, nodeB.Height
+	g.setNodeHeight(nodeA, heightB)
+	g.setNodeHeight(nodeB, heightA)
+	nodeA.DirectReports, nodeB.DirectReports = nodeB.DirectReports, nodeA.DirectReports
+	nodeA.TotalReports, nodeB.TotalReports = nodeB.TotalReports, nodeA.TotalReports
+	nodeA.Position, nodeB.Position = nodeB.Position, nodeA.Position
+	// So does the span, the subtree of each is the subtree of the other
+	spanA, okA := g.tour[a]
+	spanB, okB := g.tour[b]
+	if okA && okB {
+		g.tour[a], g.tour[b] = spanB, spanA
+	} else {
+		g.retour()
+	}
+	// A root hands it's tree over
+	for _, node := range []*Node{nodeA, nodeB} {
+		delete(g.Roots, node.ID)
+	}
+	for _, node := range []*Node{nodeA, nodeB} {
+		if node.ParID == "" {
+			g.Roots[node.ID] = node
+		}
+	}
+	sort.Slice(moved, func(i, j int) bool { return moved[i].ID < moved[j].ID })
+	return moved, nil
+}
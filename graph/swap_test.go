@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGraph_SwapNodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name    string
+		a, b    string
+		parents map[string]string // expected parent of each node that moved
+		moved   []string
+		root    string
+		err     bool
+	}{
+		{name: "cousins", a: "d", b: "f",
+			parents: map[string]string{"d": "c", "f": "b", "g": "f"},
+			moved:   []string{"d", "f", "g"}, root: "a"},
+		{name: "siblings", a: "b", b: "c",
+			parents: map[string]string{"b": "a", "c": "a", "d": "c", "e": "c", "f": "b"},
+			moved:   []string{"b", "c", "d", "e", "f"}, root: "a"},
+		{name: "parent and child", a: "b", b: "d",
+			parents: map[string]string{"d": "a", "b": "d", "e": "d", "g": "b"},
+			moved:   []string{"b", "d", "e", "g"}, root: "a"},
+		{name: "child and parent", a: "d", b: "b",
+			parents: map[string]string{"d": "a", "b": "d", "e": "d", "g": "b"},
+			moved:   []string{"b", "d", "e", "g"}, root: "a"},
+		{name: "root", a: "a", b: "c",
+			parents: map[string]string{"c": "", "a": "c", "b": "c", "f": "a"},
+			moved:   []string{"a", "b", "c", "f"}, root: "c"},
+		{name: "itself", a: "b", b: "b", err: true},
+		{name: "invalid id", a: "b", b: "x", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gr := sampleGraph(t)
+			heightA, heightB := gr.Nodes[tt.a].Height, 0
+			if nodeB, ok := gr.Nodes[tt.b]; ok {
+				heightB = nodeB.Height
+			}
+
+			moved, err := gr.SwapNodes(tt.a, tt.b)
+			if tt.err {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ids(moved)).To(Equal(tt.moved))
+			for id, parID := range tt.parents {
+				g.Expect(gr.Nodes[id].ParID).To(Equal(parID), id)
+			}
+			g.Expect(gr.Nodes[tt.a].Height).To(Equal(heightB))
+			g.Expect(gr.Nodes[tt.b].Height).To(Equal(heightA))
+			g.Expect(ids(gr.Trees())).To(Equal([]string{tt.root}))
+			g.Expect(gr.Validate()).To(BeEmpty())
+			for _, node := range gr.Nodes {
+				for id, child := range node.Children {
+					g.Expect(child.ParID).To(Equal(node.ID), id)
+				}
+			}
+		})
+	}
+}
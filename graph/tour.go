@@ -0,0 +1,98 @@
+package graph
+
+import "fmt"
+
+// tourGap is the room left between two numbers of the tour, so that new
+// leaves usually fit in without renumbering.
+const tourGap = 1 << 32
+
+// span is the place of a node in an Euler tour of the hierarchy: the number
+// given when the tour enters the node and when it leaves it. The span of a
+// node encloses the spans of all of it's descendants.
+type span struct {
+	pre, post int64
+}
+
+func (s span) encloses(o span) bool {
+	return s.pre < o.pre && o.post < s.post
+}
+
+// IsAncestor tells whether node 'a' is an ancestor of node 'b'. A node is not
+// it's own ancestor.
+func (g *Graph) IsAncestor(a, b string) (bool, error) {
+	nodeA, ok := g.Nodes[a]
+	if !ok {
+		return false, fmt.Errorf("invalid id %s", a)
+	}
+	nodeB, ok := g.Nodes[b]
+	if !ok {
+		return false, fmt.Errorf("invalid id %s", b)
+	}
+	return g.isAncestor(nodeA, nodeB), nil
+}
+
+// InSubtree tells for each of 'ids' whether it is in the subtree of node
+// 'id', the node itself included. Unknown ids are not in the subtree.
+func (g *Graph) InSubtree(id string, ids []string) (map[string]bool, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	members := make(map[string]bool, len(ids))
+	for _, other := range ids {
+		node, ok := g.Nodes[other]
+		members[other] = ok && (node == curNode || g.isAncestor(curNode, node))
+	}
+	return members, nil
+}
+
+func (g *Graph) isAncestor(a, b *Node) bool {
+	spanA, okA := g.tour[a.ID]
+	spanB, okB := g.tour[b.ID]
+	return okA && okB && spanA.encloses(spanB)
+}
+
+// retour numbers every node from scratch. The tour starts from every node
+// without a parent, nodes stuck in a cycle are left out.
+func (g *Graph) retour() {
+	g.tour = make(map[string]span, len(g.Nodes))
+	next := int64(0)
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		next += tourGap
+		pre := next
+		for _, child := range node.Children {
+			walk(child)
+		}
+		next += tourGap
+		g.tour[node.ID] = span{pre, next}
+	}
+	for _, node := range g.Nodes {
+		if _, ok := g.Nodes[node.ParID]; !ok {
+			walk(node)
+		}
+	}
+}
+
+// enter numbers the new leaf 'node' in the room left in it's parent's span,
+// after the spans of it's siblings. Takes half of the room, so that both
+// later siblings and children of the node fit. Renumbers when out of room.
+func (g *Graph) enter(node *Node) {
+	parNode, ok := g.Nodes[node.ParID]
+	if !ok {
+		g.retour()
+		return
+	}
+	parSpan := g.tour[parNode.ID]
+	lo, hi := parSpan.pre, parSpan.post
+	for _, child := range parNode.Children {
+		if s, ok := g.tour[child.ID]; ok && child != node && s.post > lo {
+			lo = s.post
+		}
+	}
+	if hi-lo < 4 {
+		g.retour()
+		return
+	}
+	g.tour[node.ID] = span{lo + 1, lo + (hi-lo)/2}
+}
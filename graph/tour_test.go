@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// walkIsAncestor answers IsAncestor the slow way, by walking up from 'b'.
+func walkIsAncestor(gr *Graph, a, b string) bool {
+	for node := gr.Nodes[gr.Nodes[b].ParID]; node != nil; node = gr.Nodes[node.ParID] {
+		if node.ID == a {
+			return true
+		}
+	}
+	return false
+}
+
+// expectTour checks IsAncestor against walkIsAncestor for every pair of nodes.
+func expectTour(g *GomegaWithT, gr *Graph) {
+	for a := range gr.Nodes {
+		for b := range gr.Nodes {
+			got, err := gr.IsAncestor(a, b)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(walkIsAncestor(gr, a, b)), fmt.Sprintf("IsAncestor(%s, %s)", a, b))
+		}
+	}
+}
+
+func TestGraph_IsAncestor(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "root", a: "a", b: "g", want: true},
+		{name: "parent", a: "d", b: "g", want: true},
+		{name: "descendant", a: "g", b: "b"},
+		{name: "itself", a: "b", b: "b"},
+		{name: "other branch", a: "c", b: "d"},
+		{name: "invalid id", a: "x", b: "b", wantErr: true},
+		{name: "invalid other id", a: "b", b: "x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			got, err := sampleGraph(t).IsAncestor(tt.a, tt.b)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGraph_InSubtree(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleGraph(t)
+
+	got, err := gr.InSubtree("b", []string{"b", "d", "g", "c", "x"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(map[string]bool{"b": true, "d": true, "g": true, "c": false, "x": false}))
+
+	_, err = gr.InSubtree("x", []string{"b"})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGraph_Tour(t *testing.T) {
+	g := NewGomegaWithT(t)
+	gr := sampleGraph(t)
+	expectTour(g, gr)
+
+	// The index follows every change of the hierarchy
+	g.Expect(gr.MoveSubtree("d", "f")).To(Succeed())
+	expectTour(g, gr)
+	g.Expect(gr.UpdateParent("b", "g")).To(Succeed())
+	expectTour(g, gr)
+	g.Expect(gr.RenameNode("b", "bob")).To(Succeed())
+	expectTour(g, gr)
+	_, err := gr.SwapNodes("c", "f")
+	g.Expect(err).NotTo(HaveOccurred())
+	expectTour(g, gr)
+	_, err = gr.SwapNodes("d", "a")
+	g.Expect(err).NotTo(HaveOccurred())
+	expectTour(g, gr)
+	_, err = gr.RemoveNode("g", DeleteReparent)
+	g.Expect(err).NotTo(HaveOccurred())
+	expectTour(g, gr)
+	expectTour(g, gr.Clone())
+
+	// Many leaves under one node run out of room and renumber
+	for i := 0; i < 100; i++ {
+		node := NewEmptyNode()
+		node.ID, node.ParID = fmt.Sprintf("n%d", i), "e"
+		if i%2 == 1 {
+			node.ParID = fmt.Sprintf("n%d", i-1)
+		}
+		g.Expect(gr.EmplaceNode(&node)).To(Succeed())
+	}
+	expectTour(g, gr)
+}
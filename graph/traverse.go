@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NoDepthLimit can be passed as max depth to walk the whole subtree.
+const NoDepthLimit = -1
+
+// Tree is a nested view of a node and its descendants. Unlike Node it holds
+// the children in a slice, so it can be limited in depth and serialized
+// in a stable order.
+type Tree struct {
+	ID            string `json:"id"`
+	ParID         string `json:"pid"`
+	Height        int    `json:"height"`
+	Position      int    `json:"position"`
+	DirectReports int    `json:"direct_reports"`
+	TotalReports  int    `json:"total_reports"`
+	Attributes
+	ChildIDs []string `json:"child_ids,omitempty"`
+	Children []*Tree  `json:"children,omitempty"`
+}
+
+// Flat returns the node as a Tree without any children.
+func (n *Node) Flat() *Tree {
+	return &Tree{ID: n.ID, ParID: n.ParID, Height: n.Height, Position: n.Position, DirectReports: n.DirectReports,
+		TotalReports: n.TotalReports, Attributes: n.Attributes}
+}
+
+// Shallow returns the node as a Tree that lists the ids of it's children, in
+// their order, instead of the children themselves.
+func (n *Node) Shallow() *Tree {
+	t := n.Flat()
+	for _, child := range sortedChildren(n) {
+		t.ChildIDs = append(t.ChildIDs, child.ID)
+	}
+	return t
+}
+
+// Tree returns the node with it's descendants nested at most 'maxDepth'
+// levels deep. Pass NoDepthLimit for the full subtree.
+func (n *Node) Tree(maxDepth int) *Tree {
+	return buildTree(n, maxDepth)
+}
+
+// Descendants returns every node under the given node in breadth first order.
+// Only nodes at most 'maxDepth' levels below the given node are returned.
+// Pass NoDepthLimit to get the full subtree.
+func (g *Graph) Descendants(id string, maxDepth int) ([]*Node, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	descendants := make([]*Node, 0)
+	level := []*Node{curNode}
+	for depth := 0; len(level) > 0 && (maxDepth == NoDepthLimit || depth < maxDepth); depth++ {
+		next := make([]*Node, 0)
+		for _, node := range level {
+			next = append(next, sortedChildren(node)...)
+		}
+		descendants = append(descendants, next...)
+		level = next
+	}
+	return descendants, nil
+}
+
+// Ancestors returns the chain of command of the given node. It starts with
+// the parent of the node and ends with the root.
+func (g *Graph) Ancestors(id string) ([]*Node, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	ancestors := make([]*Node, 0)
+	for curNode.ParID != "" {
+		parNode, ok := g.Nodes[curNode.ParID]
+		if !ok {
+			return nil, fmt.Errorf("parent %s of %s not found", curNode.ParID, curNode.ID)
+		}
+		// A broken hierarchy must not keep us here forever
+		if len(ancestors) == len(g.Nodes) {
+			return nil, fmt.Errorf("cycle detected above %s", id)
+		}
+		ancestors = append(ancestors, parNode)
+		curNode = parNode
+	}
+	return ancestors, nil
+}
+
+// LowestCommonAncestor returns the deepest node that has both given nodes
+// in it's subtree. A node is considered to be in it's own subtree, so the
+// LCA of a manager and one of it's reports is the manager itself.
+func (g *Graph) LowestCommonAncestor(a, b string) (*Node, error) {
+	up, _, err := g.pathToLCA(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return up[len(up)-1], nil
+}
+
+// Path returns the nodes on the way from 'a' to 'b'. The path goes up from
+// 'a' to the lowest common ancestor and then down to 'b'. Both ends are
+// included.
+func (g *Graph) Path(a, b string) ([]*Node, error) {
+	up, down, err := g.pathToLCA(a, b)
+	if err != nil {
+		return nil, err
+	}
+	// 'down' is collected from 'b' upwards, and ends right below the LCA
+	for i := len(down) - 1; i >= 0; i-- {
+		up = append(up, down[i])
+	}
+	return up, nil
+}
+
+// pathToLCA walks up from both nodes until they meet. Height tells which
+// node is deeper, so the deeper one is lifted first and then both move up
+// in lockstep. 'up' ends with the LCA, 'down' excludes it.
+func (g *Graph) pathToLCA(a, b string) (up, down []*Node, err error) {
+	nodeA, ok := g.Nodes[a]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid id %s", a)
+	}
+	nodeB, ok := g.Nodes[b]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid id %s", b)
+	}
+	steps := 0
+	parent := func(node *Node) (*Node, error) {
+		parNode, ok := g.Nodes[node.ParID]
+		if !ok {
+			return nil, fmt.Errorf("%s and %s are not in the same tree", a, b)
+		}
+		// A broken hierarchy must not keep us here forever
+		if steps++; steps > 2*len(g.Nodes) {
+			return nil, fmt.Errorf("cycle detected above %s or %s", a, b)
+		}
+		return parNode, nil
+	}
+
+	up, down = []*Node{nodeA}, make([]*Node, 0)
+	for nodeA.Height > nodeB.Height {
+		if nodeA, err = parent(nodeA); err != nil {
+			return nil, nil, err
+		}
+		up = append(up, nodeA)
+	}
+	for nodeB.Height > nodeA.Height {
+		down = append(down, nodeB)
+		if nodeB, err = parent(nodeB); err != nil {
+			return nil, nil, err
+		}
+	}
+	for nodeA != nodeB {
+		down = append(down, nodeB)
+		if nodeA, err = parent(nodeA); err != nil {
+			return nil, nil, err
+		}
+		if nodeB, err = parent(nodeB); err != nil {
+			return nil, nil, err
+		}
+		up = append(up, nodeA)
+	}
+	return up, down, nil
+}
+
+// Subtree returns the given node and its descendants as a nested Tree. Only
+// nodes at most 'maxDepth' levels below the given node are included.
+// Pass NoDepthLimit to get the full subtree.
+func (g *Graph) Subtree(id string, maxDepth int) (*Tree, error) {
+	curNode, ok := g.Nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("invalid id %s", id)
+	}
+	return curNode.Tree(maxDepth), nil
+}
+
+func buildTree(node *Node, maxDepth int) *Tree {
+	t := node.Flat()
+	if maxDepth == 0 {
+		return t
+	}
+	if maxDepth != NoDepthLimit {
+		maxDepth--
+	}
+	for _, child := range sortedChildren(node) {
+		t.Children = append(t.Children, buildTree(child, maxDepth))
+	}
+	return t
+}
+
+// sortedChildren returns the children of a node ordered by position, ties
+// broken by id, so that traversals are deterministic.
+func sortedChildren(node *Node) []*Node {
+	children := make([]*Node, 0, len(node.Children))
+	for _, child := range node.Children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Position != children[j].Position {
+			return children[i].Position < children[j].Position
+		}
+		return children[i].ID < children[j].ID
+	})
+	return children
+}
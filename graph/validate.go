@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProblemKind names a broken invariant of the hierarchy.
+type ProblemKind string
+
+// Problems found by Validate
+const (
+	ProblemDuplicateID ProblemKind = "duplicate_id"
+	ProblemNoRoot      ProblemKind = "no_root"
+	ProblemOrphan      ProblemKind = "orphan"
+	ProblemCycle       ProblemKind = "cycle"
+	ProblemDetached    ProblemKind = "detached"
+	ProblemHeight      ProblemKind = "height_mismatch"
+)
+
+// Problem is a single broken invariant of the hierarchy.
+type Problem struct {
+	Kind   ProblemKind `json:"kind"`
+	ID     string      `json:"id,omitempty"`
+	Detail string      `json:"detail"`
+}
+
+// placement tells where a node ends up when it's parents are followed.
+type placement struct {
+	root   string // root the node hangs under, empty if there is none
+	depth  int    // distance from root, -1 if there is no root
+	orphan bool   // parent of the node does not exist
+	cycle  bool   // node is part of a cycle
+}
+
+// Validate checks the hierarchy formed by the given nodes. It reports duplicate
+// ids, a missing root, orphans whose parent does not exist,
+// cycles, nodes that hang below an orphan or a cycle and heights that do not
+// match the actual depth. The nodes are not modified.
+func Validate(nodes []*Node) []Problem {
+	problems := make([]Problem, 0)
+	par := make(map[string]string, len(nodes))
+	height := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		if _, ok := par[node.ID]; ok {
+			problems = append(problems, Problem{Kind: ProblemDuplicateID, ID: node.ID,
+				Detail: fmt.Sprintf("%s appears more than once", node.ID)})
+			continue
+		}
+		par[node.ID] = node.ParID
+		height[node.ID] = node.Height
+	}
+
+	placed := place(par)
+	roots := rootsOf(placed)
+	if len(roots) == 0 && len(par) > 0 {
+		problems = append(problems, Problem{Kind: ProblemNoRoot, Detail: "no node without a parent"})
+	}
+	// Several roots are fine, each is the root of a tree of the forest
+
+	for _, id := range sortedIDs(par) {
+		p := placed[id]
+		switch {
+		case p.orphan:
+			problems = append(problems, Problem{Kind: ProblemOrphan, ID: id,
+				Detail: fmt.Sprintf("parent %s not found", par[id])})
+		case p.cycle:
+			problems = append(problems, Problem{Kind: ProblemCycle, ID: id,
+				Detail: fmt.Sprintf("%s is it's own ancestor", id)})
+		case p.root == "":
+			problems = append(problems, Problem{Kind: ProblemDetached, ID: id,
+				Detail: fmt.Sprintf("%s does not lead to a root", id)})
+		case p.depth != height[id]:
+			problems = append(problems, Problem{Kind: ProblemHeight, ID: id,
+				Detail: fmt.Sprintf("height is %d, actual depth is %d", height[id], p.depth)})
+		}
+	}
+	return problems
+}
+
+// Validate checks the hierarchy of the graph. See Validate.
+func (g *Graph) Validate() []Problem {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes = append(nodes, node)
+	}
+	return Validate(nodes)
+}
+
+// Repair returns the nodes that need a new parent or height to make the
+// hierarchy valid. The returned nodes are copies, the given nodes are not
+// modified. Every root keeps it's tree. Orphans and one node of each cycle are
+// attached directly under the root of the largest tree.
+// Then every height is set to the actual depth. Duplicate ids can not be
+// repaired this way and are left as they are.
+func Repair(nodes []*Node) []*Node {
+	par := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if _, ok := par[node.ID]; !ok {
+			par[node.ID] = node.ParID
+		}
+	}
+
+	placed := place(par)
+	for {
+		roots := rootsOf(placed)
+		if len(roots) == 0 {
+			if len(par) == 0 {
+				break
+			}
+			// Promote an orphan if there is one, a node of a cycle otherwise
+			candidate := ""
+			for _, id := range sortedIDs(par) {
+				if placed[id].orphan {
+					candidate = id
+					break
+				}
+				if placed[id].cycle && candidate == "" {
+					candidate = id
+				}
+			}
+			par[candidate] = ""
+			placed = place(par)
+			continue
+		}
+
+		main := largestTree(roots, placed)
+		changed := false
+		for _, id := range sortedIDs(par) {
+			p := placed[id]
+			if p.orphan {
+				par[id] = main
+				changed = true
+			}
+		}
+		// Cut one cycle at a time, a cut may reattach other cycles' nodes
+		for _, id := range sortedIDs(par) {
+			if placed[id].cycle {
+				par[id] = main
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			break
+		}
+		placed = place(par)
+	}
+
+	fixed := make([]*Node, 0)
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if seen[node.ID] {
+			continue
+		}
+		seen[node.ID] = true
+		if node.ParID == par[node.ID] && node.Height == placed[node.ID].depth {
+			continue
+		}
+		n := *node
+		n.ParID, n.Height = par[node.ID], placed[node.ID].depth
+		n.Children = make(map[string]*Node)
+		fixed = append(fixed, &n)
+	}
+	sort.Slice(fixed, func(i, j int) bool { return fixed[i].ID < fixed[j].ID })
+	return fixed
+}
+
+// place follows the parent links of every node and records where it ends up.
+// Each node is visited once, results of earlier walks are reused.
+func place(par map[string]string) map[string]placement {
+	placed := make(map[string]placement, len(par))
+	for _, id := range sortedIDs(par) {
+		path := make([]string, 0)
+		onPath := make(map[string]int)
+		base := placement{depth: -1}
+		for cur := id; ; cur = par[cur] {
+			if p, ok := placed[cur]; ok {
+				base = p
+				break
+			}
+			if i, ok := onPath[cur]; ok {
+				for _, c := range path[i:] {
+					placed[c] = placement{depth: -1, cycle: true}
+				}
+				path = path[:i]
+				break
+			}
+			parID := par[cur]
+			if parID == "" {
+				base = placement{root: cur, depth: 0}
+				placed[cur] = base
+				break
+			}
+			if _, ok := par[parID]; !ok {
+				base = placement{depth: -1, orphan: true}
+				placed[cur] = base
+				break
+			}
+			onPath[cur] = len(path)
+			path = append(path, cur)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			if base.root == "" {
+				base = placement{depth: -1}
+			} else {
+				base = placement{root: base.root, depth: base.depth + 1}
+			}
+			placed[path[i]] = base
+		}
+	}
+	return placed
+}
+
+func rootsOf(placed map[string]placement) []string {
+	roots := make([]string, 0)
+	for id, p := range placed {
+		if p.root == id {
+			roots = append(roots, id)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// largestTree returns the root with the most nodes under it. Ties go to the smallest id.
+func largestTree(roots []string, placed map[string]placement) string {
+	size := make(map[string]int, len(roots))
+	for _, p := range placed {
+		if p.root != "" {
+			size[p.root]++
+		}
+	}
+	best := roots[0]
+	for _, root := range roots[1:] {
+		if size[root] > size[best] {
+			best = root
+		}
+	}
+	return best
+}
+
+func sortedIDs(par map[string]string) []string {
+	ids := make([]string, 0, len(par))
+	for id := range par {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func rawNodes(rows ...[3]interface{}) []*Node {
+	nodes := make([]*Node, len(rows))
+	for i, r := range rows {
+		node := NewEmptyNode()
+		node.ID, node.ParID, node.Height = r[0].(string), r[1].(string), r[2].(int)
+		nodes[i] = &node
+	}
+	return nodes
+}
+
+func kinds(problems []Problem) map[string]ProblemKind {
+	out := make(map[string]ProblemKind)
+	for _, p := range problems {
+		out[p.ID] = p.Kind
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []*Node
+		want  map[string]ProblemKind
+	}{
+		{
+			name:  "Valid",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "a", 1}, [3]interface{}{"c", "b", 2}),
+			want:  map[string]ProblemKind{},
+		},
+		{
+			name:  "Forest",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "", 0}),
+			want:  map[string]ProblemKind{},
+		},
+		{
+			name:  "OrphanAndDetached",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "x", 1}, [3]interface{}{"c", "b", 2}),
+			want:  map[string]ProblemKind{"b": ProblemOrphan, "c": ProblemDetached},
+		},
+		{
+			name: "Cycle",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "c", 1},
+				[3]interface{}{"c", "b", 1}, [3]interface{}{"d", "c", 2}),
+			want: map[string]ProblemKind{"b": ProblemCycle, "c": ProblemCycle, "d": ProblemDetached},
+		},
+		{
+			name:  "Height",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "a", 3}),
+			want:  map[string]ProblemKind{"b": ProblemHeight},
+		},
+		{
+			name:  "DuplicateID",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "a", 1}, [3]interface{}{"b", "a", 1}),
+			want:  map[string]ProblemKind{"b": ProblemDuplicateID},
+		},
+		{
+			name:  "NoRoot",
+			nodes: rawNodes([3]interface{}{"a", "b", 0}, [3]interface{}{"b", "a", 1}),
+			want:  map[string]ProblemKind{"": ProblemNoRoot, "a": ProblemCycle, "b": ProblemCycle},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			g.Expect(kinds(Validate(tt.nodes))).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []*Node
+		want  map[string][2]interface{}
+	}{
+		{
+			name:  "Valid",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "a", 1}),
+			want:  map[string][2]interface{}{},
+		},
+		{
+			name: "Forest",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "", 0},
+				[3]interface{}{"c", "b", 1}, [3]interface{}{"d", "x", 1}),
+			want: map[string][2]interface{}{"d": {"b", 1}},
+		},
+		{
+			name: "OrphanCycleHeight",
+			nodes: rawNodes([3]interface{}{"a", "", 0}, [3]interface{}{"b", "x", 1},
+				[3]interface{}{"c", "b", 2}, [3]interface{}{"d", "e", 1}, [3]interface{}{"e", "d", 1}),
+			want: map[string][2]interface{}{"b": {"a", 1}, "d": {"a", 1}, "e": {"d", 2}},
+		},
+		{
+			name:  "NoRoot",
+			nodes: rawNodes([3]interface{}{"a", "b", 1}, [3]interface{}{"b", "a", 1}),
+			want:  map[string][2]interface{}{"a": {"", 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			fixed := Repair(tt.nodes)
+			got := make(map[string][2]interface{})
+			for _, n := range fixed {
+				got[n.ID] = [2]interface{}{n.ParID, n.Height}
+			}
+			g.Expect(got).To(Equal(tt.want))
+
+			// Applying the fixes leaves nothing to complain about
+			byID := make(map[string]*Node)
+			for _, n := range tt.nodes {
+				byID[n.ID] = n
+			}
+			for _, n := range fixed {
+				byID[n.ID] = n
+			}
+			repaired := make([]*Node, 0)
+			for _, n := range byID {
+				repaired = append(repaired, n)
+			}
+			g.Expect(Validate(repaired)).To(BeEmpty())
+		})
+	}
+}
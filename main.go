@@ -1,6 +1,10 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -14,10 +18,81 @@ func main() {
 		FieldNameTag: "json",
 	})
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			runImport(os.Args[2:], v)
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		}
+	}
+
 	if err := api.Serve(":8080", os.Getenv("MYSQL_CONN"), v); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// runImport imports a hierarchy from a file or stdin.
+// $ tradeshift import -format csv -file org.csv
+func runImport(args []string, v *validator.Validate) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", api.FormatCSV, "input format, csv or json")
+	file := fs.String("file", "", "input file, stdin if empty")
+	fs.Parse(args)
+
+	var in io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	n, rowErrors, err := api.ImportFile(os.Getenv("MYSQL_CONN"), *format, in, v)
+	for _, e := range rowErrors {
+		fmt.Fprintf(os.Stderr, "row %d (%s): %s\n", e.Row, e.ID, e.Error)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("imported %d nodes\n", n)
+}
+
+// runDiff prints the difference between two exports as JSON.
+// $ tradeshift diff -format csv old.csv new.csv
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", api.FormatCSV, "input format, csv or json")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		log.Fatal("usage: diff [-format csv|json] before after")
+	}
+
+	before, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer before.Close()
+	after, err := os.Open(fs.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer after.Close()
+
+	diff, err := api.DiffFiles(*format, before, after)
+	if err != nil {
+		log.Fatal(err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(diff); err != nil {
+		log.Fatal(err)
+	}
+}
+
 // $ CGO_ENABLED=0 GOOS=linux GOARCH=386 go build -a -installsuffix cgo -ldflags '-s' -o tradeshift
 // Build with ^. This creats a static binary
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+)
+
+// createEdgesSchema creates the 'edges' table holding secondary reporting lines.
+func (m *MySQL) createEdgesSchema() {
+	m.session.Exec(`CREATE TABLE IF NOT EXISTS edges (
+		FromId varchar(20) NOT NULL, ToId varchar(20) NOT NULL, Type varchar(50) NOT NULL,
+		PRIMARY KEY (FromId, ToId, Type), INDEX (ToId))`)
+}
+
+// GetEdges returns all secondary edges.
+func (m *MySQL) GetEdges() ([]*graph.Edge, error) {
+	rows, err := m.session.Query("SELECT FromId, ToId, Type FROM edges")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make([]*graph.Edge, 0)
+	for rows.Next() {
+		edge := &graph.Edge{}
+		if err := rows.Scan(&edge.From, &edge.To, &edge.Type); err != nil {
+			return nil, err
+		}
+		edges = append(edges, edge)
+	}
+	return edges, rows.Err()
+}
+
+// InsertEdge stores a secondary edge.
+func (m *MySQL) InsertEdge(edge *graph.Edge) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO edges (FromId, ToId, Type) VALUES (?, ?, ?)", edge.From, edge.To, edge.Type); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// DeleteEdge removes a secondary edge.
+func (m *MySQL) DeleteEdge(edge *graph.Edge) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM edges WHERE FromId=? AND ToId=? AND Type=?", edge.From, edge.To, edge.Type)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("edge %s -> %s of type %s not found", edge.From, edge.To, edge.Type)
+	}
+	return m.commit(tx)
+}
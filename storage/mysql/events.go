@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+	"github.com/DeshErBojhaa/tradeshift/storage"
+)
+
+// createEventsSchema creates the 'events' table, the append only change log.
+// The whole event is kept as JSON in 'Payload', the other columns are for lookups.
+func (m *MySQL) createEventsSchema() {
+	m.session.Exec(`CREATE TABLE IF NOT EXISTS events (
+		Seq bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		Kind varchar(30) NOT NULL, Actor varchar(255) NOT NULL, At datetime(6) NOT NULL,
+		Payload MEDIUMTEXT NOT NULL)`)
+}
+
+// WithEvent returns a store that appends 'e' to the change log within the
+// transaction of the next mutation. So either both the mutation and the event
+// are persisted or none of them. Seq of 'e' is set once it is appended.
+func (m *MySQL) WithEvent(e *graph.Event) storage.Persister {
+	return &MySQL{session: m.session, event: e}
+}
+
+// InitEventLog appends the given events if the change log is empty. It is
+// used to record the hierarchy that existed before the change log did.
+func (m *MySQL) InitEventLog(events []*graph.Event) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM events").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	for _, e := range events {
+		if err := insertEvent(tx, e); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEvents returns at most 'limit' events with Seq greater than 'since', in order.
+func (m *MySQL) GetEvents(since int64, limit int) ([]*graph.Event, error) {
+	rows, err := m.session.Query("SELECT Seq, Payload FROM events WHERE Seq > ? ORDER BY Seq LIMIT ?", since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*graph.Event, 0)
+	for rows.Next() {
+		var seq int64
+		var payload string
+		if err := rows.Scan(&seq, &payload); err != nil {
+			return nil, err
+		}
+		e := &graph.Event{}
+		if err := json.Unmarshal([]byte(payload), e); err != nil {
+			return nil, err
+		}
+		e.Seq = seq
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// commit appends the pending event, if there is one, and commits the transaction.
+func (m *MySQL) commit(tx *sql.Tx) error {
+	if m.event != nil {
+		if err := insertEvent(tx, m.event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertEvent(tx *sql.Tx, e *graph.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec("INSERT INTO events (Kind, Actor, At, Payload) VALUES (?, ?, ?, ?)", e.Kind, e.Actor, e.At, payload)
+	if err != nil {
+		return err
+	}
+	e.Seq, err = res.LastInsertId()
+	return err
+}
@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+)
+
+// createHistorySchema creates the 'node_versions' table. Every change of a
+// row in 'nodes' appends a copy of the row to it, valid from the time of the
+// change until the next version of the same node. A deleted node gets a
+// last version marked as deleted. Nodes that existed before the table are
+// given a version valid since the beginning of time.
+func (m *MySQL) createHistorySchema() {
+	m.session.Exec(`CREATE TABLE IF NOT EXISTS node_versions (
+		Seq bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		Id varchar(20), ParId varchar(20) NULL, Height int,
+		Name varchar(255) NOT NULL DEFAULT '', Title varchar(255) NOT NULL DEFAULT '',
+		Email varchar(255) NOT NULL DEFAULT '', Labels TEXT NULL,
+		ValidFrom datetime(6) NOT NULL, Deleted bool NOT NULL DEFAULT false,
+		INDEX (Id, ValidFrom))`)
+	m.session.Exec("ALTER TABLE node_versions ADD COLUMN Position int NOT NULL DEFAULT 0")
+	m.session.Exec(`INSERT INTO node_versions (Id, ParId, Height, Position, Name, Title, Email, Labels, ValidFrom)
+		SELECT n.Id, n.ParId, n.Height, n.Position, n.Name, n.Title, n.Email, n.Labels, '1970-01-01'
+		FROM nodes n WHERE NOT EXISTS (SELECT 1 FROM node_versions v WHERE v.Id = n.Id)`)
+}
+
+// GetNodesAsOf returns the nodes as they were at the given time, in the same
+// shape as GetNodes.
+func (m *MySQL) GetNodesAsOf(at time.Time) ([]*graph.Node, error) {
+	rows, err := m.session.Query(`SELECT v.Id, v.ParId, v.Height, v.Position, v.Name, v.Title, v.Email, v.Labels
+		FROM node_versions v
+		JOIN (SELECT MAX(Seq) AS Seq FROM node_versions WHERE ValidFrom <= ? GROUP BY Id) latest ON v.Seq = latest.Seq
+		WHERE NOT v.Deleted`, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes, err := scanNodes(rows)
+	if err != nil {
+		return nil, err
+	}
+	linkChildren(nodes)
+	return nodes, nil
+}
+
+// recordVersions appends the current rows of the given nodes to 'node_versions'.
+// For a delete it must be called before the rows are removed.
+func recordVersions(tx *sql.Tx, at time.Time, deleted bool, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare(`INSERT INTO node_versions (Id, ParId, Height, Position, Name, Title, Email, Labels, ValidFrom, Deleted)
+		SELECT Id, ParId, Height, Position, Name, Title, Email, Labels, ?, ? FROM nodes WHERE Id=?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, id := range ids {
+		if _, err := stmt.Exec(at, deleted, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// childIDs returns the ids of the direct children of node 'id'.
+func childIDs(tx *sql.Tx, id string) ([]string, error) {
+	rows, err := tx.Query("SELECT Id FROM nodes WHERE ParId=?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var childID string
+		if err := rows.Scan(&childID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, childID)
+	}
+	return ids, rows.Err()
+}
+
+func nodeIDs(nodes []*graph.Node) []string {
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+	return ids
+}
+
+// now is the time a change takes effect. All versions written by one
+// transaction share it.
+func now() time.Time {
+	return time.Now().UTC()
+}
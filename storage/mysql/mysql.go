@@ -2,8 +2,11 @@ package mysql
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 
 	"github.com/DeshErBojhaa/tradeshift/graph"
 	// ...
@@ -13,6 +16,7 @@ import (
 // MySQL ...
 type MySQL struct {
 	session *sql.DB
+	event   *graph.Event // appended to the change log by the next mutation
 }
 
 // CreateSchema bootstraps the initial database schema.
@@ -21,6 +25,15 @@ func (m *MySQL) CreateSchema() {
 	m.session.Exec(`CREATE DATABASE IF NOT EXISTS tradeshift DEFAULT CHARACTER SET = 'utf8' DEFAULT COLLATE 'utf8_general_ci';`)
 	m.session.Exec(`USE tradeshift;`)
 	m.session.Exec("CREATE TABLE IF NOT EXISTS nodes (Id varchar(20), ParId varchar(20) NULL, Height int)")
+	// Attribute columns were added later. On an up to date schema these fail with duplicate column.
+	m.session.Exec("ALTER TABLE nodes ADD COLUMN Name varchar(255) NOT NULL DEFAULT ''")
+	m.session.Exec("ALTER TABLE nodes ADD COLUMN Title varchar(255) NOT NULL DEFAULT ''")
+	m.session.Exec("ALTER TABLE nodes ADD COLUMN Email varchar(255) NOT NULL DEFAULT ''")
+	m.session.Exec("ALTER TABLE nodes ADD COLUMN Labels TEXT NULL")
+	m.session.Exec("ALTER TABLE nodes ADD COLUMN Position int NOT NULL DEFAULT 0")
+	m.createHistorySchema()
+	m.createEdgesSchema()
+	m.createEventsSchema()
 }
 
 // NewMySQLStore creates an instance of MySQLStore with the given connection string.
@@ -51,18 +64,56 @@ func (m *MySQL) InsertNode(node *graph.Node) error {
 	// Will not be called if committed prior
 	defer tx.Rollback()
 
-	stmtNode, err := tx.Prepare("INSERT INTO nodes (Id, ParId, Height) VALUES (?, ?, ?)")
+	labels, err := marshalLabels(node.Labels)
+	if err != nil {
+		return err
+	}
+
+	stmtNode, err := tx.Prepare("INSERT INTO nodes (Id, ParId, Height, Position, Name, Title, Email, Labels) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 	defer stmtNode.Close()
 
-	if _, err := stmtNode.Exec(node.ID, node.ParID, node.Height); err != nil {
+	if _, err := stmtNode.Exec(node.ID, node.ParID, node.Height, node.Position, node.Name, node.Title, node.Email, labels); err != nil {
 		log.Printf("error happened executing node %#v", err)
 		return err
 	}
+	if err := recordVersions(tx, now(), false, []string{node.ID}); err != nil {
+		return err
+	}
 
-	return tx.Commit()
+	return m.commit(tx)
+}
+
+// InsertNodes creates all the given nodes within a single transaction. Either
+// every node is inserted or none of them. Parents must come before their children.
+func (m *MySQL) InsertNodes(nodes []*graph.Node) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmtNode, err := tx.Prepare("INSERT INTO nodes (Id, ParId, Height, Position, Name, Title, Email, Labels) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmtNode.Close()
+
+	for _, node := range nodes {
+		labels, err := marshalLabels(node.Labels)
+		if err != nil {
+			return err
+		}
+		if _, err := stmtNode.Exec(node.ID, node.ParID, node.Height, node.Position, node.Name, node.Title, node.Email, labels); err != nil {
+			return fmt.Errorf("inserting %s: %v", node.ID, err)
+		}
+	}
+	if err := recordVersions(tx, now(), false, nodeIDs(nodes)); err != nil {
+		return err
+	}
+	return m.commit(tx)
 }
 
 // GetNodes returns all the nodes. Intrensic info of a node is persisted in
@@ -71,26 +122,46 @@ func (m *MySQL) InsertNode(node *graph.Node) error {
 // relation. Create child list for each node from that parent child relation.
 // Return error at any point and avoid transaction.
 func (m *MySQL) GetNodes() ([]*graph.Node, error) {
-	nodes := make([]*graph.Node, 0)
-	nodeMap := make(map[string]*graph.Node)
-
-	rows, err := m.session.Query("SELECT Id, ParId, Height FROM nodes")
+	rows, err := m.session.Query("SELECT Id, ParId, Height, Position, Name, Title, Email, Labels FROM nodes")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	nodes, err := scanNodes(rows)
+	if err != nil {
+		return nil, err
+	}
+	linkChildren(nodes)
+	return nodes, nil
+}
+
+// scanNodes reads nodes from rows of Id, ParId, Height, Position, Name, Title,
+// Email, Labels. A NULL ParId is a root, same as an empty one.
+func scanNodes(rows *sql.Rows) ([]*graph.Node, error) {
+	nodes := make([]*graph.Node, 0)
 	for rows.Next() {
 		node := graph.NewEmptyNode()
-		rows.Scan(&node.ID, &node.ParID, &node.Height)
+		var parID, labels sql.NullString
+		if err := rows.Scan(&node.ID, &parID, &node.Height, &node.Position, &node.Name, &node.Title, &node.Email, &labels); err != nil {
+			return nil, err
+		}
+		node.ParID = parID.String
+		var err error
+		if node.Labels, err = unmarshalLabels(labels); err != nil {
+			return nil, fmt.Errorf("labels of %s: %v", node.ID, err)
+		}
 		nodes = append(nodes, &node)
-		nodeMap[node.ID] = &node
 	}
+	return nodes, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+// linkChildren creates child list for each node from the parent child relation.
+func linkChildren(nodes []*graph.Node) {
+	nodeMap := make(map[string]*graph.Node, len(nodes))
+	for _, node := range nodes {
+		nodeMap[node.ID] = node
 	}
-
 	for _, node := range nodes {
 		parNode := nodeMap[node.ParID]
 		if parNode == nil { // Root
@@ -98,14 +169,16 @@ func (m *MySQL) GetNodes() ([]*graph.Node, error) {
 		}
 		parNode.Children[node.ID] = node
 	}
-
-	return nodes, nil
 }
 
 // UpdateParent changes parent of 'curNode' to the 'targetNode'.
 // If root is given as curNode an error is returned. It also updates
-// the parent child relationship within a transaction.
-func (m *MySQL) UpdateParent(curNode, targetNode *graph.Node) error {
+// the parent child relationship within a transaction. The children of
+// 'curNode' move one level up, so every one of it's 'descendants' gets one
+// level shallower. Nodes that get a new parent go after their new siblings.
+// 'curNode' is given as it was before the move, 'targetNode' as it is after
+// it, as the target may be one of the lifted descendants.
+func (m *MySQL) UpdateParent(curNode, targetNode *graph.Node, descendants []*graph.Node) error {
 	if curNode.ParID == "" {
 		return fmt.Errorf("can not change parent of the root node")
 	}
@@ -119,7 +192,14 @@ func (m *MySQL) UpdateParent(curNode, targetNode *graph.Node) error {
 	// 2. Cur node's parent will change
 
 	// 1
-	stmtLevelUpChildren, err := tx.Prepare("UPDATE nodes SET ParId=?, Height=Height-1 WHERE ParId=?")
+	next, err := nextPosition(tx, curNode.ParID, "")
+	if err != nil {
+		return err
+	}
+	if err := appendChildren(tx, curNode, descendants, next); err != nil {
+		return err
+	}
+	stmtLevelUpChildren, err := tx.Prepare("UPDATE nodes SET ParId=? WHERE ParId=?")
 	if err != nil {
 		return err
 	}
@@ -127,15 +207,340 @@ func (m *MySQL) UpdateParent(curNode, targetNode *graph.Node) error {
 	if _, err := stmtLevelUpChildren.Exec(curNode.ParID, curNode.ID); err != nil {
 		return err
 	}
+	if err := execEach(tx, "UPDATE nodes SET Height=Height-1 WHERE Id=?", descendants); err != nil {
+		return err
+	}
 
 	// 2
-	stmtUpdatePar, err := tx.Prepare("UPDATE nodes SET ParId=?, Height=? WHERE Id=?")
+	if next, err = nextPosition(tx, targetNode.ID, curNode.ID); err != nil {
+		return err
+	}
+	stmtUpdatePar, err := tx.Prepare("UPDATE nodes SET ParId=?, Height=?, Position=? WHERE Id=?")
+	if err != nil {
+		return err
+	}
+	defer stmtUpdatePar.Close()
+	if _, err := stmtUpdatePar.Exec(targetNode.ID, targetNode.Height+1, next, curNode.ID); err != nil {
+		return err
+	}
+	if err := recordVersions(tx, now(), false, append(nodeIDs(descendants), curNode.ID)); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// MoveSubtree changes parent of 'curNode' to the 'targetNode'. Unlike UpdateParent
+// the children of 'curNode' are not lifted, they move along with it. Heights of
+// 'curNode' and all of it's 'descendants' are shifted within a transaction.
+// 'curNode' goes after it's new siblings.
+func (m *MySQL) MoveSubtree(curNode, targetNode *graph.Node, descendants []*graph.Node) error {
+	if curNode.ParID == "" {
+		return fmt.Errorf("can not change parent of the root node")
+	}
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	next, err := nextPosition(tx, targetNode.ID, curNode.ID)
+	if err != nil {
+		return err
+	}
+	stmtUpdatePar, err := tx.Prepare("UPDATE nodes SET ParId=?, Height=?, Position=? WHERE Id=?")
 	if err != nil {
 		return err
 	}
 	defer stmtUpdatePar.Close()
-	if _, err := stmtUpdatePar.Exec(targetNode.ID, targetNode.Height+1, curNode.ID); err != nil {
+	if _, err := stmtUpdatePar.Exec(targetNode.ID, targetNode.Height+1, next, curNode.ID); err != nil {
 		return err
 	}
-	return tx.Commit()
+
+	delta := targetNode.Height + 1 - curNode.Height
+	if delta == 0 {
+		if err := recordVersions(tx, now(), false, []string{curNode.ID}); err != nil {
+			return err
+		}
+		return m.commit(tx)
+	}
+	stmtShiftHeight, err := tx.Prepare("UPDATE nodes SET Height=Height+? WHERE Id=?")
+	if err != nil {
+		return err
+	}
+	defer stmtShiftHeight.Close()
+	for _, node := range descendants {
+		if _, err := stmtShiftHeight.Exec(delta, node.ID); err != nil {
+			return err
+		}
+	}
+	if err := recordVersions(tx, now(), false, append(nodeIDs(descendants), curNode.ID)); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// DeleteNode removes 'curNode' within a transaction. With graph.DeleteReparent
+// the children of 'curNode' move one level up, after their new siblings, and
+// every one of 'descendants' gets one level shallower. With graph.DeleteCascade
+// all of 'descendants' are removed too. With graph.DeleteRefuse nothing is
+// removed if 'curNode' has children.
+func (m *MySQL) DeleteNode(curNode *graph.Node, strategy graph.DeleteStrategy, descendants []*graph.Node) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	at := now()
+	switch strategy {
+	case graph.DeleteRefuse:
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM nodes WHERE ParId=?", curNode.ID).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return graph.ErrHasChildren
+		}
+	case graph.DeleteReparent:
+		next, err := nextPosition(tx, curNode.ParID, "")
+		if err != nil {
+			return err
+		}
+		if err := appendChildren(tx, curNode, descendants, next); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE nodes SET ParId=? WHERE ParId=?", curNode.ParID, curNode.ID); err != nil {
+			return err
+		}
+		if err := execEach(tx, "UPDATE nodes SET Height=Height-1 WHERE Id=?", descendants); err != nil {
+			return err
+		}
+		if err := recordVersions(tx, at, false, nodeIDs(descendants)); err != nil {
+			return err
+		}
+	case graph.DeleteCascade:
+		if err := recordVersions(tx, at, true, nodeIDs(descendants)); err != nil {
+			return err
+		}
+		if err := execEach(tx, "DELETE FROM nodes WHERE Id=?", descendants); err != nil {
+			return err
+		}
+		if err := execEach(tx, "DELETE FROM edges WHERE FromId=? OR ToId=?", descendants); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid delete strategy %s", strategy)
+	}
+
+	if err := recordVersions(tx, at, true, []string{curNode.ID}); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM nodes WHERE Id=?", curNode.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM edges WHERE FromId=? OR ToId=?", curNode.ID, curNode.ID); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// UpdateAttributes replaces the attributes of 'curNode'.
+func (m *MySQL) UpdateAttributes(curNode *graph.Node, attrs graph.Attributes) error {
+	labels, err := marshalLabels(attrs.Labels)
+	if err != nil {
+		return err
+	}
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE nodes SET Name=?, Title=?, Email=?, Labels=? WHERE Id=?",
+		attrs.Name, attrs.Title, attrs.Email, labels, curNode.ID); err != nil {
+		return err
+	}
+	if err := recordVersions(tx, now(), false, []string{curNode.ID}); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// UpdateNodes overwrites parent, height and position of the given nodes within a transaction.
+func (m *MySQL) UpdateNodes(nodes []*graph.Node) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE nodes SET ParId=?, Height=?, Position=? WHERE Id=?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, node := range nodes {
+		if _, err := stmt.Exec(node.ParID, node.Height, node.Position, node.ID); err != nil {
+			return err
+		}
+	}
+	if err := recordVersions(tx, now(), false, nodeIDs(nodes)); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// RenameNode changes the id of 'curNode' to 'newID' within a transaction.
+// The parent of it's children and it's edges are rewritten along with it.
+func (m *MySQL) RenameNode(curNode *graph.Node, newID string) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	at := now()
+	// Under the old id the node is gone from now on
+	if err := recordVersions(tx, at, true, []string{curNode.ID}); err != nil {
+		return err
+	}
+	children, err := childIDs(tx, curNode.ID)
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		"UPDATE nodes SET Id=? WHERE Id=?",
+		"UPDATE nodes SET ParId=? WHERE ParId=?",
+		"UPDATE edges SET FromId=? WHERE FromId=?",
+		"UPDATE edges SET ToId=? WHERE ToId=?",
+	} {
+		if _, err := tx.Exec(query, newID, curNode.ID); err != nil {
+			return err
+		}
+	}
+	if err := recordVersions(tx, at, false, append(children, newID)); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// ReplaceNodes overwrites the rows of 'nodes' and removes the rows of 'removed',
+// along with their edges, within a transaction. Nodes without a row are created.
+func (m *MySQL) ReplaceNodes(nodes, removed []*graph.Node) error {
+	tx, err := m.session.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	at := now()
+	if err := recordVersions(tx, at, true, nodeIDs(removed)); err != nil {
+		return err
+	}
+	if err := execEach(tx, "DELETE FROM nodes WHERE Id=?", removed); err != nil {
+		return err
+	}
+	if err := execEach(tx, "DELETE FROM edges WHERE FromId=? OR ToId=?", removed); err != nil {
+		return err
+	}
+	if err := execEach(tx, "DELETE FROM nodes WHERE Id=?", nodes); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		labels, err := marshalLabels(node.Labels)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO nodes (Id, ParId, Height, Position, Name, Title, Email, Labels) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			node.ID, node.ParID, node.Height, node.Position, node.Name, node.Title, node.Email, labels); err != nil {
+			return err
+		}
+	}
+	if err := recordVersions(tx, at, false, nodeIDs(nodes)); err != nil {
+		return err
+	}
+	return m.commit(tx)
+}
+
+// marshalLabels encodes labels as a JSON object. Empty labels are stored as NULL.
+func marshalLabels(labels map[string]string) (sql.NullString, error) {
+	if len(labels) == 0 {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func unmarshalLabels(labels sql.NullString) (map[string]string, error) {
+	if !labels.Valid || labels.String == "" {
+		return nil, nil
+	}
+	out := make(map[string]string)
+	if err := json.Unmarshal([]byte(labels.String), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// execEach runs the prepared 'query' once for each node. Every placeholder of
+// the query gets the node id.
+func execEach(tx *sql.Tx, query string, nodes []*graph.Node) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	placeholders := strings.Count(query, "?")
+	for _, node := range nodes {
+		args := make([]interface{}, placeholders)
+		for i := range args {
+			args[i] = node.ID
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextPosition returns the position after the last child of 'parID', leaving
+// 'except' out. Mirrors the graph, a node that gets a new parent goes last.
+func nextPosition(tx *sql.Tx, parID, except string) (int, error) {
+	var next int
+	err := tx.QueryRow("SELECT COALESCE(MAX(Position)+1, 0) FROM nodes WHERE ParId=? AND Id<>?", parID, except).Scan(&next)
+	return next, err
+}
+
+// appendChildren gives the children of 'curNode' found in 'descendants' the
+// positions from 'next' on, keeping their order.
+func appendChildren(tx *sql.Tx, curNode *graph.Node, descendants []*graph.Node, next int) error {
+	children := make([]*graph.Node, 0)
+	for _, node := range descendants {
+		if node.ParID == curNode.ID {
+			children = append(children, node)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Position != children[j].Position {
+			return children[i].Position < children[j].Position
+		}
+		return children[i].ID < children[j].ID
+	})
+	stmt, err := tx.Prepare("UPDATE nodes SET Position=? WHERE Id=?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, child := range children {
+		if _, err := stmt.Exec(next+i, child.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
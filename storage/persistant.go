@@ -1,10 +1,27 @@
 package storage
 
-import "github.com/DeshErBojhaa/tradeshift/graph"
+import (
+	"time"
+
+	"github.com/DeshErBojhaa/tradeshift/graph"
+)
 
 // Persister exposes behaviour for underlying static types.
 type Persister interface {
 	GetNodes() ([]*graph.Node, error)
+	GetNodesAsOf(at time.Time) ([]*graph.Node, error)
 	InsertNode(node *graph.Node) error
-	UpdateParent(curNode, targetNode *graph.Node) error
+	InsertNodes(nodes []*graph.Node) error
+	UpdateParent(curNode, targetNode *graph.Node, descendants []*graph.Node) error
+	MoveSubtree(curNode, targetNode *graph.Node, descendants []*graph.Node) error
+	DeleteNode(curNode *graph.Node, strategy graph.DeleteStrategy, descendants []*graph.Node) error
+	UpdateAttributes(curNode *graph.Node, attrs graph.Attributes) error
+	RenameNode(curNode *graph.Node, newID string) error
+	UpdateNodes(nodes []*graph.Node) error
+	ReplaceNodes(nodes, removed []*graph.Node) error
+	GetEdges() ([]*graph.Edge, error)
+	InsertEdge(edge *graph.Edge) error
+	DeleteEdge(edge *graph.Edge) error
+	WithEvent(e *graph.Event) Persister
+	GetEvents(since int64, limit int) ([]*graph.Event, error)
 }
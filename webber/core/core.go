@@ -1,6 +1,9 @@
 package core
 
-import "net/http"
+import (
+	"io"
+	"net/http"
+)
 
 // Package level constants
 const (
@@ -8,9 +11,13 @@ const (
 	HeaderXContentTypeOptions = "X-Content-Type-Options"
 	NoSniff                   = "nosniff"
 	MediaTypeJSON             = "application/json"
+	MediaTypeCSV              = "text/csv"
+	MediaTypeText             = "text/plain"
 	MethodGet                 = "GET"
 	MethodPost                = "POST"
 	MethodUpdate              = "PUT"
+	MethodDelete              = "DELETE"
+	MethodPatch               = "PATCH"
 )
 
 // ResponseWriter ...
@@ -22,6 +29,8 @@ type Handler func(r Request) ResponseWriter
 // Request ...
 type Request interface {
 	PathParam(key string) (string, bool)
+	QueryParam(key string) (string, bool)
 	JSON(target interface{}) error
+	Body() io.Reader
 	Header(key string) string
 }
@@ -5,6 +5,7 @@ package webber
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -35,7 +36,21 @@ func (r *BasicRequest) PathParam(key string) (string, bool) {
 	return v, ok
 }
 
+// QueryParam gets the optional query parameters
+func (r *BasicRequest) QueryParam(key string) (string, bool) {
+	values, ok := r.httpRequest.URL.Query()[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
 // JSON marshals the body into json format
 func (r *BasicRequest) JSON(target interface{}) error {
 	return json.NewDecoder(r.httpRequest.Body).Decode(target)
 }
+
+// Body gives the raw body of the request
+func (r *BasicRequest) Body() io.Reader {
+	return r.httpRequest.Body
+}
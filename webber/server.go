@@ -47,6 +47,16 @@ func (s *Server) PUT(path string, h core.Handler) {
 	s.register(path, h, core.MethodUpdate)
 }
 
+// PATCH attaches router to corresponding handler.
+func (s *Server) PATCH(path string, h core.Handler) {
+	s.register(path, h, core.MethodPatch)
+}
+
+// DELETE attaches router to corresponding handler.
+func (s *Server) DELETE(path string, h core.Handler) {
+	s.register(path, h, core.MethodDelete)
+}
+
 // Serve starts the service
 func (s *Server) Serve() error {
 	s.httpServer.Handler = s.router